@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// showcaseSize is how many of the latest badges GetUser shows.
+const showcaseSize = 6
+
+func GetBadges(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"data":  services.BadgeDefinitions(),
+		"kinds": services.BadgeKinds,
+	})
+}
+
+func validBadge(badge *models.BadgeDefinition) string {
+	badge.Name = strings.TrimSpace(badge.Name)
+	badge.Genre = strings.TrimSpace(badge.Genre)
+
+	if len(badge.Name) < 2 || len(badge.Name) > 60 {
+		return "Name must be between 2 and 60 characters"
+	}
+
+	if len(badge.Description) > 255 || len(badge.Icon) > 255 {
+		return "Description and icon must be at most 255 characters"
+	}
+
+	if !services.ValidBadgeKind(badge.Kind) {
+		return fmt.Sprintf("Unknown badge kind %q", badge.Kind)
+	}
+
+	if badge.Threshold < 1 {
+		return "Threshold must be at least 1"
+	}
+
+	if badge.Genre != "" && badge.Kind != models.BadgeGenreBooks {
+		return "Only genre_books badges can have a genre"
+	}
+
+	var existing models.BadgeDefinition
+
+	db.GetDB().Where("LOWER(name) = LOWER(?) AND id <> ?", badge.Name, badge.ID).First(&existing)
+
+	if existing.ID > 0 {
+		return "A badge with this name already exists"
+	}
+
+	return ""
+}
+
+func CreateBadge(c *fiber.Ctx) error {
+
+	var badge models.BadgeDefinition
+
+	if err := c.BodyParser(&badge); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	badge.ID = 0
+
+	if message := validBadge(&badge); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Create(&badge)
+
+	return c.JSON(fiber.Map{
+		"data":  "Badge created successfully, backfill it to award it to existing users",
+		"badge": badge,
+	})
+}
+
+// ModifyBadge changes a badge definition, users who already have the badge
+// keep it even if they no longer meet the new threshold.
+func ModifyBadge(c *fiber.Ctx) error {
+
+	var badge models.BadgeDefinition
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&badge)
+
+	if badge.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Badge not found",
+		})
+	}
+
+	id := badge.ID
+
+	if err := c.BodyParser(&badge); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	badge.ID = id
+
+	if message := validBadge(&badge); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Model(&badge).Updates(map[string]interface{}{
+		"name":        badge.Name,
+		"description": badge.Description,
+		"icon":        badge.Icon,
+		"kind":        badge.Kind,
+		"threshold":   badge.Threshold,
+		"genre":       badge.Genre,
+	})
+
+	return c.JSON(fiber.Map{
+		"data":  "Badge updated successfully",
+		"badge": badge,
+	})
+}
+
+func DeleteBadge(c *fiber.Ctx) error {
+
+	var badge models.BadgeDefinition
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&badge)
+
+	if badge.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Badge not found",
+		})
+	}
+
+	db.GetDB().Where("badge_id = ?", badge.ID).Delete(&models.UserBadge{})
+	db.GetDB().Delete(&badge)
+
+	return c.JSON(fiber.Map{
+		"data": "Badge deleted successfully",
+	})
+}
+
+// BackfillBadges awards badges to existing users, all badges or only the one
+// given by ?badge_id=. It goes over every user so it runs in the background,
+// the admin who started it is notified with the result.
+func BackfillBadges(c *fiber.Ctx) error {
+
+	adminId := loggedUserID(c)
+
+	badgeId := 0
+
+	if value := c.Query("badge_id"); value != "" {
+		id, err := strconv.Atoi(value)
+
+		if err != nil || id <= 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"data": "Invalid badge id",
+			})
+		}
+
+		badgeId = id
+	}
+
+	if badgeId > 0 {
+		var badge models.BadgeDefinition
+
+		db.GetDB().Where("id = ?", badgeId).First(&badge)
+
+		if badge.ID == 0 {
+			return c.Status(404).JSON(fiber.Map{
+				"data": "Badge not found",
+			})
+		}
+	}
+
+	go func() {
+		awarded, err := services.BackfillBadges(badgeId)
+
+		message := fmt.Sprintf("Badge backfill finished, %d badges awarded", awarded)
+
+		if err != nil {
+			message = fmt.Sprintf("Badge backfill failed after awarding %d badges: %v", awarded, err)
+		}
+
+		fmt.Println(message)
+
+		if err := services.Notify(db.GetDB(), adminId, "badge_backfill", message); err != nil {
+			fmt.Println("Failed to notify admin", adminId, err)
+		}
+	}()
+
+	return c.Status(202).JSON(fiber.Map{
+		"data": "Badge backfill started, you will be notified when it is done",
+	})
+}
+
+func GetUserBadges(c *fiber.Ctx) error {
+
+	userId, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || userId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": services.UserBadges(userId, 0),
+	})
+}
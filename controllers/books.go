@@ -2,16 +2,22 @@ package controllers
 
 import (
 	"fmt"
+	"mime/multipart"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/catalinfl/readit-api/db"
 	"github.com/catalinfl/readit-api/middlewares"
 	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
+const sessionsPageSize = 30
+
 func GetBooks(c *fiber.Ctx) error {
 
 	getInfiniteScrollBooks(c)
@@ -54,6 +60,8 @@ func CreateBook(c *fiber.Ctx) error {
 
 	db.GetDB().Create(&book)
 
+	services.InvalidateSimilarityIndex()
+
 	return c.JSON(fiber.Map{
 		"data": "Book created successfully",
 	})
@@ -176,11 +184,15 @@ func CreateUserBook(c *fiber.Ctx) error {
 
 	var user models.User
 
-	db.GetDB().Where("name = ?", t).First(&user)
+	db.GetDB().Where("name = ?", t["name"]).First(&user)
 
 	userBook.UserID = uint(user.ID)
 	userBook.BookID = uint(userBookMap["book_id"].(float64))
-	userBook.PagesRead = uint(userBookMap["pages_read"].(float64))
+	userBook.Format = models.FormatPaper
+
+	if format, ok := userBookMap["format"].(string); ok && format != "" {
+		userBook.Format = format
+	}
 
 	var existingUserBook models.UserBooks
 
@@ -196,13 +208,101 @@ func CreateUserBook(c *fiber.Ctx) error {
 
 	db.GetDB().Where("user_id = ? AND book_id = ?", userBook.UserID, userBook.BookID).First(&existingUserBook)
 
+	if err := services.CheckFormat(userBook.Format, existingBook); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": capitalize(err.Error()),
+		})
+	}
+
 	if existingUserBook.UserBooksID > 0 {
+		// adding a book that was finished or abandoned again is a re-read,
+		// possibly in another format
+		if _, ok := userBookMap["format"].(string); ok {
+			existingUserBook.Format = userBook.Format
+		}
+
+		err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+			_, err := services.StartReread(tx, &existingUserBook, time.Now())
+			return err
+		})
+
+		if err == services.ErrStillReading {
+			return c.Status(400).JSON(fiber.Map{
+				"data": "You already have this book",
+			})
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"data": "Failed to start a new read-through",
+			})
+		}
+
+		services.ReadingEvent(user.ID)
+
+		return c.JSON(fiber.Map{
+			"data":      "Started a new read-through",
+			"user_book": existingUserBook,
+		})
+	}
+
+	now := time.Now()
+
+	userBook.BookState = models.StateWantToRead
+
+	if state, ok := userBookMap["book_state"].(string); ok && state != "" {
+		if err := services.TransitionState(&userBook, state, now); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"data": capitalize(err.Error()),
+			})
+		}
+	}
+
+	progress, _ := userBookMap["progress"].(float64)
+
+	if pages, ok := userBookMap["pages_read"].(float64); ok && userBook.Format == models.FormatPaper && progress == 0 {
+		progress = pages
+	}
+
+	if err := services.CheckProgress(userBook.Format, existingBook, progress); err != nil {
 		return c.Status(400).JSON(fiber.Map{
-			"data": "You already have this book",
+			"data": capitalize(err.Error()),
 		})
 	}
 
-	db.GetDB().Create(&userBook)
+	services.SetProgress(&userBook, existingBook, progress)
+	userBook.PagesRead = services.PageEquivalent(userBook.Format, existingBook, progress)
+
+	services.ApplyProgress(&userBook, existingBook, now)
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&userBook).Error; err != nil {
+			return err
+		}
+
+		if _, err := services.NewReadThrough(tx, &userBook); err != nil {
+			return err
+		}
+
+		if userBook.Progress == 0 {
+			return nil
+		}
+
+		// progress made before adding the book counts as the first session
+		firstPage := uint(0)
+
+		_, err := services.RecordSession(tx, &userBook, services.SessionInput{StartPage: &firstPage, EndPage: userBook.PagesRead, Progress: userBook.Progress})
+
+		return err
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to add book",
+		})
+	}
+
+	services.ReadingEvent(user.ID)
 
 	return c.JSON(fiber.Map{
 		"data": "User book created successfully",
@@ -232,6 +332,8 @@ func DeleteBook(c *fiber.Ctx) error {
 
 	db.GetDB().Delete(&book)
 
+	services.InvalidateSimilarityIndex()
+
 	return c.JSON(fiber.Map{
 		"data": "Book deleted successfully",
 	})
@@ -239,6 +341,14 @@ func DeleteBook(c *fiber.Ctx) error {
 
 func UpdateReadingBook(c *fiber.Ctx) error {
 
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
 	request := make(map[string]interface{})
 
 	if err := c.BodyParser(&request); err != nil {
@@ -253,15 +363,354 @@ func UpdateReadingBook(c *fiber.Ctx) error {
 
 	db.GetDB().Where("user_books_id = ?", userBookMap["user_books_id"]).First(&userBook)
 
-	userBook.PagesRead = uint(userBookMap["pages_read"].(float64))
+	if userBook.UserBooksID == 0 || int(userBook.UserID) != userId {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	input, message := sessionInput(userBookMap, userBook.Format)
+
+	if message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", userBook.BookID).First(&book)
+
+	if err := services.CheckFormat(userBook.Format, book); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": capitalize(err.Error()),
+		})
+	}
+
+	if userBook.Format == models.FormatPaper && book.Pages > 0 && input.Progress > float64(book.Pages) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": fmt.Sprintf("This book has only %d pages", book.Pages),
+		})
+	}
+
+	if err := services.CheckProgress(userBook.Format, book, input.Progress); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": capitalize(err.Error()),
+		})
+	}
+
+	input.EndPage = services.PageEquivalent(userBook.Format, book, input.Progress)
+
+	var session models.ReadingSession
 
-	db.GetDB().Model(&userBook).Updates(userBook)
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		session, err = services.RecordSession(tx, &userBook, input)
+
+		if err != nil {
+			return err
+		}
+
+		services.SetProgress(&userBook, book, userBook.Progress)
+		services.ApplyProgress(&userBook, book, time.Now())
+
+		err = tx.Model(&userBook).Updates(map[string]interface{}{
+			"pages_read":  userBook.PagesRead,
+			"progress":    userBook.Progress,
+			"percent":     userBook.Percent,
+			"book_state":  userBook.BookState,
+			"started_at":  userBook.StartedAt,
+			"finished_at": userBook.FinishedAt,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return services.SyncReadThrough(tx, &userBook)
+	})
+
+	if err == services.ErrSessionInFuture {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Reading time can't be in the future",
+		})
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to save reading progress",
+		})
+	}
+
+	services.ReadingEvent(userId)
 
 	return c.JSON(fiber.Map{
 		"data":       "User book updated successfully",
 		"pages_read": userBook.PagesRead,
+		"format":     userBook.Format,
+		"progress":   userBook.Progress,
+		"percent":    userBook.Percent,
+		"book_state": userBook.BookState,
+		"session":    session,
+	})
+
+}
+
+// sessionInput reads a progress update: progress is where the reader got to
+// in the format's unit (pages_read still works for paper books), start_page
+// (paper only), read_at (RFC3339) and duration_minutes are optional.
+func sessionInput(request map[string]interface{}, format string) (services.SessionInput, string) {
+	var input services.SessionInput
+
+	progress, ok := request["progress"].(float64)
+
+	if !ok && format == models.FormatPaper {
+		progress, ok = request["pages_read"].(float64)
+	}
+
+	if !ok || progress < 0 {
+		return input, fmt.Sprintf("Progress must be a positive number of %s", services.FormatUnits[format])
+	}
+
+	input.Progress = progress
+
+	if value, ok := request["start_page"]; ok && value != nil && format == models.FormatPaper {
+		startPage, ok := value.(float64)
+
+		if !ok || startPage < 0 {
+			return input, "Start page must be a positive number"
+		}
+
+		start := uint(startPage)
+		input.StartPage = &start
+	}
+
+	if value, ok := request["duration_minutes"]; ok && value != nil {
+		duration, ok := value.(float64)
+
+		if !ok || duration < 1 || duration > services.MaxSessionMinutes {
+			return input, fmt.Sprintf("Duration must be between 1 and %d minutes", services.MaxSessionMinutes)
+		}
+
+		minutes := uint(duration)
+		input.DurationMinutes = &minutes
+	}
+
+	readAt, ok := parseOptionalTime(request["read_at"])
+
+	if !ok {
+		return input, "Read at must be an RFC3339 date"
+	}
+
+	if readAt != nil {
+		input.ReadAt = *readAt
+	}
+
+	return input, ""
+}
+
+func GetReadingSessions(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", c.Params("userBooksId")).First(&userBook)
+
+	if userBook.UserBooksID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	// when someone reads is only shared with their friends
+	if int(userBook.UserID) != userId && !services.AreFriends(int(userBook.UserID), userId) {
+		return c.Status(403).JSON(fiber.Map{
+			"data": "Reading sessions are only visible to friends",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	var sessions []models.ReadingSession
+
+	db.GetDB().Where("user_books_id = ?", userBook.UserBooksID).
+		Order("read_at desc, id desc").
+		Offset((page - 1) * sessionsPageSize).
+		Limit(sessionsPageSize + 1).
+		Find(&sessions)
+
+	hasMore := len(sessions) > sessionsPageSize
+
+	if hasMore {
+		sessions = sessions[:sessionsPageSize]
+	}
+
+	return c.JSON(fiber.Map{
+		"data":       sessions,
+		"pages_read": userBook.PagesRead,
+		"hasMore":    hasMore,
+	})
+}
+
+func UpdateReadingState(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var request struct {
+		UserBooksID int    `json:"user_books_id"`
+		State       string `json:"state"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.UserBooksID == 0 || request.State == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", request.UserBooksID).First(&userBook)
+
+	if userBook.UserBooksID == 0 || int(userBook.UserID) != userId {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	if err := services.TransitionState(&userBook, request.State, time.Now()); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": capitalize(err.Error()),
+		})
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&userBook).Updates(map[string]interface{}{
+			"book_state":  userBook.BookState,
+			"started_at":  userBook.StartedAt,
+			"finished_at": userBook.FinishedAt,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return services.SyncReadThrough(tx, &userBook)
 	})
 
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to update reading state",
+		})
+	}
+
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data":      "Reading state updated successfully",
+		"user_book": userBook,
+	})
+}
+
+// UpdateUserBookFormat switches a user book to another format, progress is
+// carried over through its percentage.
+func UpdateUserBookFormat(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var request struct {
+		UserBooksID int    `json:"user_books_id"`
+		Format      string `json:"format"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.UserBooksID == 0 || request.Format == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", request.UserBooksID).First(&userBook)
+
+	if userBook.UserBooksID == 0 || int(userBook.UserID) != userId {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", userBook.BookID).First(&book)
+
+	if err := services.CheckFormat(request.Format, book); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": capitalize(err.Error()),
+		})
+	}
+
+	progress := services.ConvertProgress(userBook, book, request.Format)
+
+	userBook.Format = request.Format
+	services.SetProgress(&userBook, book, progress)
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&userBook).Updates(map[string]interface{}{
+			"format":   userBook.Format,
+			"progress": userBook.Progress,
+			"percent":  userBook.Percent,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return services.SyncReadThrough(tx, &userBook)
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to update format",
+		})
+	}
+
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data":      "Format updated successfully",
+		"user_book": userBook,
+	})
+}
+
+func capitalize(message string) string {
+	if message == "" {
+		return message
+	}
+
+	return strings.ToUpper(message[:1]) + message[1:]
 }
 
 func UpdateGenre(c *fiber.Ctx) error {
@@ -290,6 +739,8 @@ func UpdateGenre(c *fiber.Ctx) error {
 
 	db.GetDB().Save(&book)
 
+	services.InvalidateSimilarityIndex()
+
 	return c.JSON(fiber.Map{
 		"data": book.Genre,
 	})
@@ -297,6 +748,14 @@ func UpdateGenre(c *fiber.Ctx) error {
 
 func DeleteUserBook(c *fiber.Ctx) error {
 
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
 	id := c.Params("bookId")
 
 	if id == "" || id == "0" {
@@ -309,13 +768,31 @@ func DeleteUserBook(c *fiber.Ctx) error {
 
 	db.GetDB().Where("user_books_id = ?", id).First(&userBook)
 
-	if userBook.UserBooksID == 0 {
+	if userBook.UserBooksID == 0 || int(userBook.UserID) != userId {
 		return c.Status(404).JSON(fiber.Map{
 			"data": "User book not found",
 		})
 	}
 
-	db.GetDB().Delete(&userBook)
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_books_id = ?", userBook.UserBooksID).Delete(&models.ReadingSession{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_books_id = ?", userBook.UserBooksID).Delete(&models.ReadThrough{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&userBook).Error
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to delete user book",
+		})
+	}
+
+	services.ReadingEvent(userId)
 
 	return c.JSON(fiber.Map{
 		"data": "User book deleted successfully",
@@ -377,6 +854,8 @@ func ModifyBook(c *fiber.Ctx) error {
 			book.Language = value.(string)
 		case "pages":
 			book.Pages = uint(value.(float64)) // JSON numbers are float64
+		case "audio_minutes":
+			book.AudioMinutes = uint(value.(float64))
 		case "genre":
 			book.Genre = value.(string)
 		case "publisher":
@@ -394,6 +873,8 @@ func ModifyBook(c *fiber.Ctx) error {
 		})
 	}
 
+	services.InvalidateSimilarityIndex()
+
 	return c.JSON(fiber.Map{
 		"data": "Book updated successfully",
 		"book": book,
@@ -422,12 +903,59 @@ func DeleteBookLibrarian(c *fiber.Ctx) error {
 
 	db.GetDB().Delete(&book)
 
+	services.InvalidateSimilarityIndex()
+
 	return c.JSON(fiber.Map{
 		"data": "Book deleted successfully",
 	})
 
 }
 
+const (
+	maxPhotoSize = 1 << 20
+	// camera shots of a back cover are larger than the pictures we store
+	maxScanPhotoSize = 4 << 20
+)
+
+// uploadedPhoto reads the single "photos" file of a multipart request, it is
+// shared by every endpoint that takes a picture so the checks stay the same.
+func uploadedPhoto(c *fiber.Ctx, maxSize int64) (*multipart.FileHeader, string) {
+	form, err := c.MultipartForm()
+
+	if err != nil {
+		return nil, "Invalid request"
+	}
+
+	file := form.File["photos"]
+
+	if len(file) != 1 {
+		return nil, "You need to send just one photo"
+	}
+
+	photo := file[0]
+
+	if photo.Size > maxSize {
+		return nil, "File size too big"
+	}
+
+	return photo, ""
+}
+
+func savePhoto(c *fiber.Ctx, photo *multipart.FileHeader, dirPath string, name string) (string, error) {
+	filePath := dirPath + name
+
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		os.MkdirAll(dirPath, os.ModePerm)
+	}
+
+	if err := c.SaveFile(photo, filePath); err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+
+	return filePath, nil
+}
+
 func AddPhotosForBooks(c *fiber.Ctx) error {
 
 	token := c.Cookies("jwt_token")
@@ -458,42 +986,17 @@ func AddPhotosForBooks(c *fiber.Ctx) error {
 		})
 	}
 
-	form, err := c.MultipartForm()
-
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"data": "Invalid request",
-		})
-	}
-
-	file := form.File["photos"]
-
-	if len(file) != 1 {
-		return c.Status(400).JSON(fiber.Map{
-			"data": "You need to send just one photo",
-		})
-	}
-
-	photo := file[0]
+	photo, message := uploadedPhoto(c, maxPhotoSize)
 
-	if photo.Size > 1<<20 {
+	if photo == nil {
 		return c.Status(400).JSON(fiber.Map{
-			"data": "File size too big",
+			"data": message,
 		})
 	}
 
-	dirPath := "/app/books/"
-
-	filePath := dirPath + strconv.Itoa(int(book.ID))
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-
-		os.Mkdir(dirPath, os.ModePerm)
+	filePath, err := savePhoto(c, photo, "/app/books/", strconv.Itoa(int(book.ID)))
 
-	}
-
-	if err := c.SaveFile(photo, filePath); err != nil {
-		fmt.Println(err)
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"data": "Failed to save photo",
 		})
@@ -557,7 +1060,8 @@ func GetBook(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"data": book,
+		"data":   book,
+		"rating": bookRatingSummary(book.ID),
 	})
 
 }
@@ -0,0 +1,411 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type branchAvailability struct {
+	BranchID  *int   `json:"branch_id"`
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	Total     int    `json:"total"`
+	Available int    `json:"available"`
+}
+
+type branchCopy struct {
+	models.Copy
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+func branchExists(id int) bool {
+	var count int64
+
+	db.GetDB().Model(&models.Branch{}).Where("id = ?", id).Count(&count)
+
+	return count > 0
+}
+
+// queryBranchID reads the branch_id filter of the librarian views, "mine"
+// stands for the branch the logged librarian works at.
+func queryBranchID(c *fiber.Ctx) string {
+	branchId := c.Query("branch_id")
+
+	if branchId != "mine" {
+		return branchId
+	}
+
+	var user models.User
+
+	db.GetDB().Where("id = ?", loggedUserID(c)).First(&user)
+
+	if user.BranchID == nil {
+		// a librarian without a branch sees nothing rather than everything
+		return "0"
+	}
+
+	return strconv.Itoa(*user.BranchID)
+}
+
+func GetBranches(c *fiber.Ctx) error {
+
+	var branches []models.Branch
+
+	db.GetDB().Order("name").Find(&branches)
+
+	return c.JSON(fiber.Map{
+		"data": branches,
+	})
+}
+
+func validBranch(branch *models.Branch) string {
+	branch.Name = strings.TrimSpace(branch.Name)
+
+	if len(branch.Name) < 2 || len(branch.Name) > 100 {
+		return "Name must be between 2 and 100 characters"
+	}
+
+	if len(branch.Address) > 255 {
+		return "Address must be at most 255 characters"
+	}
+
+	if len(branch.Phone) > 30 {
+		return "Phone must be at most 30 characters"
+	}
+
+	var existing models.Branch
+
+	db.GetDB().Where("LOWER(name) = LOWER(?) AND id <> ?", branch.Name, branch.ID).First(&existing)
+
+	if existing.ID > 0 {
+		return "A branch with this name already exists"
+	}
+
+	return ""
+}
+
+func CreateBranch(c *fiber.Ctx) error {
+
+	var branch models.Branch
+
+	if err := c.BodyParser(&branch); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	branch.ID = 0
+
+	if message := validBranch(&branch); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Create(&branch)
+
+	return c.JSON(fiber.Map{
+		"data":   "Branch created successfully",
+		"branch": branch,
+	})
+}
+
+func ModifyBranch(c *fiber.Ctx) error {
+
+	var branch models.Branch
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&branch)
+
+	if branch.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Branch not found",
+		})
+	}
+
+	id := branch.ID
+
+	if err := c.BodyParser(&branch); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	branch.ID = id
+
+	if message := validBranch(&branch); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Model(&branch).Updates(map[string]interface{}{
+		"name":    branch.Name,
+		"address": branch.Address,
+		"phone":   branch.Phone,
+	})
+
+	return c.JSON(fiber.Map{
+		"data":   "Branch updated successfully",
+		"branch": branch,
+	})
+}
+
+func DeleteBranch(c *fiber.Ctx) error {
+
+	var branch models.Branch
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&branch)
+
+	if branch.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Branch not found",
+		})
+	}
+
+	var copies int64
+
+	db.GetDB().Model(&models.Copy{}).Where("branch_id = ?", branch.ID).Count(&copies)
+
+	if copies > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Branch still has copies, move them to another branch first",
+		})
+	}
+
+	var transfers int64
+
+	db.GetDB().Model(&models.TransferRequest{}).
+		Where("(from_branch_id = ? OR to_branch_id = ?) AND status IN ?", branch.ID, branch.ID, []string{models.TransferRequested, models.TransferInTransit}).
+		Count(&transfers)
+
+	if transfers > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Branch has open transfers",
+		})
+	}
+
+	db.GetDB().Model(&models.User{}).Where("branch_id = ?", branch.ID).Update("branch_id", nil)
+	db.GetDB().Delete(&branch)
+
+	return c.JSON(fiber.Map{
+		"data": "Branch deleted successfully",
+	})
+}
+
+func AssignLibrarianBranch(c *fiber.Ctx) error {
+
+	var user models.User
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&user)
+
+	if user.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User not found",
+		})
+	}
+
+	if !user.Librarian {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Only librarians can be assigned to a branch",
+		})
+	}
+
+	var request struct {
+		BranchID *int `json:"branch_id"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if request.BranchID != nil && *request.BranchID == 0 {
+		request.BranchID = nil
+	}
+
+	if request.BranchID != nil && !branchExists(*request.BranchID) {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Branch not found",
+		})
+	}
+
+	db.GetDB().Model(&user).Update("branch_id", request.BranchID)
+
+	return c.JSON(fiber.Map{
+		"data": "Librarian branch updated successfully",
+	})
+}
+
+func GetBranchInventory(c *fiber.Ctx) error {
+
+	var branch models.Branch
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&branch)
+
+	if branch.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Branch not found",
+		})
+	}
+
+	query := db.GetDB().Table("copies").
+		Select("copies.*, books.title, books.author").
+		Joins("JOIN books ON books.id = copies.book_id").
+		Where("copies.branch_id = ?", branch.ID)
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("copies.status = ?", status)
+	}
+
+	var copies []branchCopy
+
+	query.Order("books.title, copies.id").Scan(&copies)
+
+	counts := make(map[string]int)
+
+	for _, bookCopy := range copies {
+		counts[bookCopy.Status]++
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   copies,
+		"branch": branch,
+		"counts": counts,
+	})
+}
+
+// GetBookAvailability lists how many copies of a book each branch holds and
+// how many of them are on the shelf right now.
+func GetBookAvailability(c *fiber.Ctx) error {
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Book not found",
+		})
+	}
+
+	var availability []branchAvailability
+
+	db.GetDB().Table("copies").
+		Select("copies.branch_id, COALESCE(branches.name, '') AS name, COALESCE(branches.address, '') AS address, COUNT(*) AS total, SUM(CASE WHEN copies.status = ? THEN 1 ELSE 0 END) AS available", models.CopyAvailable).
+		Joins("LEFT JOIN branches ON branches.id = copies.branch_id").
+		Where("copies.book_id = ? AND copies.status <> ?", book.ID, models.CopyLost).
+		Group("copies.branch_id, branches.name, branches.address").
+		Order("available DESC, name").
+		Scan(&availability)
+
+	available := 0
+
+	for _, branch := range availability {
+		available += branch.Available
+	}
+
+	return c.JSON(fiber.Map{
+		"data":      availability,
+		"available": available,
+	})
+}
+
+func GetTransfers(c *fiber.Ctx) error {
+
+	query := db.GetDB().Model(&models.TransferRequest{})
+
+	if branchId := queryBranchID(c); branchId != "" {
+		query = query.Where("from_branch_id = ? OR to_branch_id = ?", branchId, branchId)
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{models.TransferRequested, models.TransferInTransit})
+	}
+
+	var transfers []models.TransferRequest
+
+	query.Order("created_at").Find(&transfers)
+
+	return c.JSON(fiber.Map{
+		"data": transfers,
+	})
+}
+
+func CreateTransfer(c *fiber.Ctx) error {
+
+	var request struct {
+		CopyID     int    `json:"copy_id"`
+		ToBranchID int    `json:"to_branch_id"`
+		Note       string `json:"note"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.CopyID == 0 || request.ToBranchID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if len(request.Note) > 255 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Note must be at most 255 characters",
+		})
+	}
+
+	transfer, err := services.RequestTransfer(request.CopyID, request.ToBranchID, loggedUserID(c), request.Note)
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     "Transfer requested successfully",
+		"transfer": transfer,
+	})
+}
+
+func transferAction(c *fiber.Ctx, action func(id int) (models.TransferRequest, error), message string) error {
+
+	id, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || id == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	transfer, err := action(id)
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     message,
+		"transfer": transfer,
+	})
+}
+
+func ShipTransfer(c *fiber.Ctx) error {
+	return transferAction(c, func(id int) (models.TransferRequest, error) {
+		return services.ShipTransfer(id, loggedUserID(c))
+	}, "Transfer shipped successfully")
+}
+
+func ReceiveTransfer(c *fiber.Ctx) error {
+	return transferAction(c, func(id int) (models.TransferRequest, error) {
+		return services.ReceiveTransfer(id, loggedUserID(c))
+	}, "Transfer received successfully")
+}
+
+func CancelTransfer(c *fiber.Ctx) error {
+	return transferAction(c, services.CancelTransfer, "Transfer cancelled successfully")
+}
@@ -0,0 +1,501 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+const transactionsPageSize = 50
+
+var copyConditions = map[string]bool{
+	"new":     true,
+	"good":    true,
+	"fair":    true,
+	"poor":    true,
+	"damaged": true,
+}
+
+var copyStatuses = map[string]bool{
+	models.CopyAvailable:   true,
+	models.CopyLost:        true,
+	models.CopyMaintenance: true,
+}
+
+func circulationError(c *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrCopyNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Copy not found",
+		})
+	case services.ErrUserNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User not found",
+		})
+	case services.ErrLoanNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Loan not found",
+		})
+	case services.ErrCopyUnavailable:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy is not available",
+		})
+	case services.ErrNoOpenLoan:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy is not checked out",
+		})
+	case services.ErrRenewalLimit:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Loan can't be renewed anymore",
+		})
+	case services.ErrReservedForOther:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy is reserved for another reader",
+		})
+	case services.ErrHoldsWaiting:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Loan can't be renewed, other readers are waiting for this book",
+		})
+	case services.ErrBookNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Book not found",
+		})
+	case services.ErrHoldNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Hold not found",
+		})
+	case services.ErrAlreadyOnHold:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You already have a hold on this book",
+		})
+	case services.ErrAlreadyBorrowed:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You already borrowed this book",
+		})
+	case services.ErrHoldNotActive:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Hold is not active anymore",
+		})
+	case services.ErrFinesOutstanding:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Patron has outstanding fines above the allowed limit",
+		})
+	case services.ErrInvalidAmount:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Amount must be positive and not greater than the balance",
+		})
+	case services.ErrBranchNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Branch not found",
+		})
+	case services.ErrSameBranch:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy is already at this branch",
+		})
+	case services.ErrTransferNotFound:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Transfer not found",
+		})
+	case services.ErrTransferState:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Transfer can't be moved to this state",
+		})
+	case services.ErrTransferPending:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy already has an open transfer",
+		})
+	default:
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Circulation operation failed",
+		})
+	}
+}
+
+func GetBookCopies(c *fiber.Ctx) error {
+
+	query := db.GetDB().Where("book_id = ?", c.Params("bookId"))
+
+	if branchId := queryBranchID(c); branchId != "" {
+		query = query.Where("branch_id = ?", branchId)
+	}
+
+	var copies []models.Copy
+
+	query.Order("id").Find(&copies)
+
+	return c.JSON(fiber.Map{
+		"data": copies,
+	})
+}
+
+func CreateCopy(c *fiber.Ctx) error {
+
+	var bookCopy models.Copy
+
+	if err := c.BodyParser(&bookCopy); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	bookCopy.Barcode = strings.TrimSpace(bookCopy.Barcode)
+
+	if len(bookCopy.Barcode) < 3 || len(bookCopy.Barcode) > 50 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Barcode must be between 3 and 50 characters",
+		})
+	}
+
+	if bookCopy.Condition == "" {
+		bookCopy.Condition = "good"
+	}
+
+	if !copyConditions[bookCopy.Condition] {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Condition must be new, good, fair, poor or damaged",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", bookCopy.BookID).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Book doesn't exist",
+		})
+	}
+
+	if bookCopy.BranchID != nil && !branchExists(*bookCopy.BranchID) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Branch doesn't exist",
+		})
+	}
+
+	var existingCopy models.Copy
+
+	db.GetDB().Where("barcode = ?", bookCopy.Barcode).First(&existingCopy)
+
+	if existingCopy.ID > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Barcode already in use",
+		})
+	}
+
+	bookCopy.ID = 0
+	bookCopy.Status = models.CopyAvailable
+
+	db.GetDB().Create(&bookCopy)
+
+	return c.JSON(fiber.Map{
+		"data": "Copy created successfully",
+		"copy": bookCopy,
+	})
+}
+
+func ModifyCopy(c *fiber.Ctx) error {
+
+	var bookCopy models.Copy
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&bookCopy)
+
+	if bookCopy.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Copy not found",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	for key, value := range request {
+		switch key {
+		case "barcode":
+			barcode, _ := value.(string)
+			barcode = strings.TrimSpace(barcode)
+
+			if len(barcode) < 3 || len(barcode) > 50 {
+				return c.Status(400).JSON(fiber.Map{
+					"data": "Barcode must be between 3 and 50 characters",
+				})
+			}
+
+			var existingCopy models.Copy
+
+			db.GetDB().Where("barcode = ? AND id <> ?", barcode, bookCopy.ID).First(&existingCopy)
+
+			if existingCopy.ID > 0 {
+				return c.Status(400).JSON(fiber.Map{
+					"data": "Barcode already in use",
+				})
+			}
+
+			bookCopy.Barcode = barcode
+		case "condition":
+			condition, _ := value.(string)
+
+			if !copyConditions[condition] {
+				return c.Status(400).JSON(fiber.Map{
+					"data": "Condition must be new, good, fair, poor or damaged",
+				})
+			}
+
+			bookCopy.Condition = condition
+		case "shelf_location":
+			bookCopy.ShelfLocation, _ = value.(string)
+		case "branch_id":
+			// moving a copy between branches normally goes through a
+			// transfer, this is for fixing where a copy was registered
+			if bookCopy.Status == models.CopyInTransit {
+				return c.Status(400).JSON(fiber.Map{
+					"data": "Copy is in transit, receive the transfer first",
+				})
+			}
+
+			bookCopy.BranchID = nil
+
+			if branchId, ok := value.(float64); ok && branchId != 0 {
+				id := int(branchId)
+
+				if !branchExists(id) {
+					return c.Status(400).JSON(fiber.Map{
+						"data": "Branch doesn't exist",
+					})
+				}
+
+				bookCopy.BranchID = &id
+			}
+		}
+	}
+
+	db.GetDB().Model(&bookCopy).Updates(map[string]interface{}{
+		"barcode":        bookCopy.Barcode,
+		"condition":      bookCopy.Condition,
+		"shelf_location": bookCopy.ShelfLocation,
+		"branch_id":      bookCopy.BranchID,
+	})
+
+	if status, ok := request["status"].(string); ok && status != bookCopy.Status {
+		if !copyStatuses[status] {
+			return c.Status(400).JSON(fiber.Map{
+				"data": "Status must be available, lost or maintenance",
+			})
+		}
+
+		note, _ := request["note"].(string)
+
+		updated, err := services.SetCopyStatus(bookCopy.ID, status, loggedUserID(c), note)
+
+		if err != nil {
+			return circulationError(c, err)
+		}
+
+		bookCopy.Status = updated.Status
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Copy updated successfully",
+		"copy": bookCopy,
+	})
+}
+
+func DeleteCopy(c *fiber.Ctx) error {
+
+	var bookCopy models.Copy
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&bookCopy)
+
+	if bookCopy.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Copy not found",
+		})
+	}
+
+	if bookCopy.Status == models.CopyCheckedOut {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy is checked out, check it in first",
+		})
+	}
+
+	if bookCopy.Status == models.CopyInTransit {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Copy is in transit, receive the transfer first",
+		})
+	}
+
+	db.GetDB().Delete(&bookCopy)
+
+	return c.JSON(fiber.Map{
+		"data": "Copy deleted successfully",
+	})
+}
+
+func CheckoutCopy(c *fiber.Ctx) error {
+
+	var request struct {
+		Barcode string `json:"barcode"`
+		UserID  int    `json:"user_id"`
+		Days    int    `json:"days"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.Barcode == "" || request.UserID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	loan, err := services.Checkout(request.Barcode, request.UserID, loggedUserID(c), request.Days)
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Copy checked out successfully",
+		"loan": loan,
+	})
+}
+
+func CheckinCopy(c *fiber.Ctx) error {
+
+	var request struct {
+		Barcode string `json:"barcode"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.Barcode == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	loan, err := services.Checkin(request.Barcode, loggedUserID(c))
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Copy checked in successfully",
+		"loan": loan,
+	})
+}
+
+func RenewLoan(c *fiber.Ctx) error {
+
+	loanId, err := strconv.Atoi(c.Params("loanId"))
+
+	if err != nil || loanId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	loan, err := services.Renew(loanId, loggedUserID(c))
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Loan renewed successfully",
+		"loan": loan,
+	})
+}
+
+func GetLoans(c *fiber.Ctx) error {
+
+	query := db.GetDB().Model(&models.Loan{})
+
+	if userId := c.Query("user_id"); userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+
+	if bookId := c.Query("book_id"); bookId != "" {
+		query = query.Where("book_id = ?", bookId)
+	}
+
+	if branchId := queryBranchID(c); branchId != "" {
+		query = query.Where("branch_id = ?", branchId)
+	}
+
+	if c.QueryBool("open", true) {
+		query = query.Where("returned_at IS NULL")
+	}
+
+	var loans []models.Loan
+
+	query.Order("due_at").Find(&loans)
+
+	return c.JSON(fiber.Map{
+		"data": loans,
+	})
+}
+
+func GetMyLoans(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var loans []models.Loan
+
+	db.GetDB().Where("user_id = ? AND returned_at IS NULL", userId).Order("due_at").Find(&loans)
+
+	return c.JSON(fiber.Map{
+		"data": loans,
+	})
+}
+
+func GetCirculationTransactions(c *fiber.Ctx) error {
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	query := db.GetDB().Model(&models.CirculationTransaction{})
+
+	if copyId := c.Query("copy_id"); copyId != "" {
+		query = query.Where("copy_id = ?", copyId)
+	}
+
+	if userId := c.Query("user_id"); userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+
+	if branchId := queryBranchID(c); branchId != "" {
+		query = query.Where("copy_id IN (?)", db.GetDB().Model(&models.Copy{}).Select("id").Where("branch_id = ?", branchId))
+	}
+
+	var transactions []models.CirculationTransaction
+
+	query.Order("created_at desc, id desc").
+		Offset((page - 1) * transactionsPageSize).
+		Limit(transactionsPageSize + 1).
+		Find(&transactions)
+
+	hasMore := len(transactions) > transactionsPageSize
+
+	if hasMore {
+		transactions = transactions[:transactionsPageSize]
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    transactions,
+		"hasMore": hasMore,
+	})
+}
@@ -0,0 +1,430 @@
+package controllers
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type featuredItemResponse struct {
+	models.FeaturedItem
+	Book models.Book `json:"book"`
+}
+
+type featuredCollectionResponse struct {
+	models.FeaturedCollection
+	Items []featuredItemResponse `json:"items"`
+}
+
+func parseOptionalTime(value interface{}) (*time.Time, bool) {
+	if value == nil {
+		return nil, true
+	}
+
+	text, ok := value.(string)
+
+	if !ok {
+		return nil, false
+	}
+
+	if text == "" {
+		return nil, true
+	}
+
+	parsed, err := time.Parse(time.RFC3339, text)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return &parsed, true
+}
+
+func applyFeaturedFields(collection *models.FeaturedCollection, request map[string]interface{}) string {
+	for key, value := range request {
+		switch key {
+		case "title":
+			collection.Title, _ = value.(string)
+			collection.Title = strings.TrimSpace(collection.Title)
+		case "description":
+			collection.Description, _ = value.(string)
+		case "position":
+			position, _ := value.(float64)
+			collection.Position = int(position)
+		case "starts_at":
+			startsAt, ok := parseOptionalTime(value)
+
+			if !ok || startsAt == nil {
+				return "Start date must be an RFC3339 date"
+			}
+
+			collection.StartsAt = *startsAt
+		case "ends_at":
+			endsAt, ok := parseOptionalTime(value)
+
+			if !ok {
+				return "End date must be an RFC3339 date"
+			}
+
+			collection.EndsAt = endsAt
+		}
+	}
+
+	if len(collection.Title) < 3 || len(collection.Title) > 100 {
+		return "Title must be between 3 and 100 characters"
+	}
+
+	if len(collection.Description) > 1000 {
+		return "Description must be at most 1000 characters"
+	}
+
+	if collection.EndsAt != nil && !collection.EndsAt.After(collection.StartsAt) {
+		return "End date must be after the start date"
+	}
+
+	return ""
+}
+
+func featuredWithItems(collections []models.FeaturedCollection) []featuredCollectionResponse {
+	response := make([]featuredCollectionResponse, 0, len(collections))
+
+	if len(collections) == 0 {
+		return response
+	}
+
+	ids := make([]int, 0, len(collections))
+
+	for _, collection := range collections {
+		ids = append(ids, collection.ID)
+	}
+
+	var items []models.FeaturedItem
+
+	db.GetDB().Where("collection_id IN ?", ids).Order("position, id").Find(&items)
+
+	bookIds := make([]int, 0, len(items))
+
+	for _, item := range items {
+		bookIds = append(bookIds, item.BookID)
+	}
+
+	books := make(map[int]models.Book)
+
+	if len(bookIds) > 0 {
+		var found []models.Book
+
+		db.GetDB().Where("id IN ?", bookIds).Find(&found)
+
+		for _, book := range found {
+			books[book.ID] = book
+		}
+	}
+
+	itemsByCollection := make(map[int][]featuredItemResponse)
+
+	for _, item := range items {
+		if book, ok := books[item.BookID]; ok {
+			itemsByCollection[item.CollectionID] = append(itemsByCollection[item.CollectionID], featuredItemResponse{FeaturedItem: item, Book: book})
+		}
+	}
+
+	for _, collection := range collections {
+		collectionItems := itemsByCollection[collection.ID]
+
+		if collectionItems == nil {
+			collectionItems = []featuredItemResponse{}
+		}
+
+		response = append(response, featuredCollectionResponse{FeaturedCollection: collection, Items: collectionItems})
+	}
+
+	return response
+}
+
+func GetFeatured(c *fiber.Ctx) error {
+
+	now := time.Now()
+
+	var collections []models.FeaturedCollection
+
+	db.GetDB().Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Order("position, starts_at desc").
+		Find(&collections)
+
+	return c.JSON(fiber.Map{
+		"data": featuredWithItems(collections),
+	})
+}
+
+func GetFeaturedBanner(c *fiber.Ctx) error {
+
+	var collection models.FeaturedCollection
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&collection)
+
+	if collection.ID == 0 || collection.Banner == "" {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Banner not found",
+		})
+	}
+
+	return c.Status(200).SendFile(collection.Banner)
+}
+
+func GetAllFeaturedLibrarian(c *fiber.Ctx) error {
+
+	var collections []models.FeaturedCollection
+
+	db.GetDB().Order("starts_at desc").Find(&collections)
+
+	return c.JSON(fiber.Map{
+		"data": featuredWithItems(collections),
+	})
+}
+
+func CreateFeaturedCollection(c *fiber.Ctx) error {
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	collection := models.FeaturedCollection{
+		StartsAt:  time.Now(),
+		CreatedBy: loggedUserID(c),
+	}
+
+	if message := applyFeaturedFields(&collection, request); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Create(&collection)
+
+	return c.JSON(fiber.Map{
+		"data":       "Featured collection created successfully",
+		"collection": collection,
+	})
+}
+
+func ModifyFeaturedCollection(c *fiber.Ctx) error {
+
+	var collection models.FeaturedCollection
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&collection)
+
+	if collection.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Featured collection not found",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if message := applyFeaturedFields(&collection, request); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Save(&collection)
+
+	return c.JSON(fiber.Map{
+		"data":       "Featured collection updated successfully",
+		"collection": collection,
+	})
+}
+
+func DeleteFeaturedCollection(c *fiber.Ctx) error {
+
+	var collection models.FeaturedCollection
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&collection)
+
+	if collection.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Featured collection not found",
+		})
+	}
+
+	db.GetDB().Transaction(func(tx *gorm.DB) error {
+		tx.Where("collection_id = ?", collection.ID).Delete(&models.FeaturedItem{})
+
+		return tx.Delete(&collection).Error
+	})
+
+	if collection.Banner != "" {
+		os.Remove(collection.Banner)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Featured collection deleted successfully",
+	})
+}
+
+func UploadFeaturedBanner(c *fiber.Ctx) error {
+
+	var collection models.FeaturedCollection
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&collection)
+
+	if collection.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Featured collection not found",
+		})
+	}
+
+	photo, message := uploadedPhoto(c, maxPhotoSize)
+
+	if photo == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	filePath, err := savePhoto(c, photo, "/app/featured/", strconv.Itoa(collection.ID))
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to save photo",
+		})
+	}
+
+	collection.Banner = filePath
+
+	db.GetDB().Save(&collection)
+
+	return c.JSON(fiber.Map{
+		"data": "Banner uploaded successfully",
+	})
+}
+
+func AddFeaturedItem(c *fiber.Ctx) error {
+
+	var collection models.FeaturedCollection
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&collection)
+
+	if collection.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Featured collection not found",
+		})
+	}
+
+	var item models.FeaturedItem
+
+	if err := c.BodyParser(&item); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if len(item.Blurb) > 500 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Blurb must be at most 500 characters",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", item.BookID).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Book doesn't exist",
+		})
+	}
+
+	var lastPosition int
+
+	db.GetDB().Model(&models.FeaturedItem{}).Where("collection_id = ?", collection.ID).Select("coalesce(max(position), 0)").Scan(&lastPosition)
+
+	item.ID = 0
+	item.CollectionID = collection.ID
+	item.Position = lastPosition + 1
+
+	db.GetDB().Create(&item)
+
+	return c.JSON(fiber.Map{
+		"data": "Book added to featured collection",
+		"item": item,
+	})
+}
+
+func DeleteFeaturedItem(c *fiber.Ctx) error {
+
+	result := db.GetDB().Where("id = ? AND collection_id = ?", c.Params("itemId"), c.Params("id")).Delete(&models.FeaturedItem{})
+
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Item not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Book removed from featured collection",
+	})
+}
+
+func ReorderFeaturedItems(c *fiber.Ctx) error {
+
+	var request struct {
+		ItemIDs []int `json:"item_ids"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var count int64
+
+	db.GetDB().Model(&models.FeaturedItem{}).Where("collection_id = ? AND id IN ?", c.Params("id"), request.ItemIDs).Count(&count)
+
+	var total int64
+
+	db.GetDB().Model(&models.FeaturedItem{}).Where("collection_id = ?", c.Params("id")).Count(&total)
+
+	if len(request.ItemIDs) == 0 || int(count) != len(request.ItemIDs) || count != total {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "The new order must contain every item of the collection exactly once",
+		})
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		for position, id := range request.ItemIDs {
+			if err := tx.Model(&models.FeaturedItem{}).Where("id = ?", id).Update("position", position+1).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to reorder collection",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Featured collection reordered successfully",
+	})
+}
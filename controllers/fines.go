@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type overdueLoanResponse struct {
+	models.Loan
+	DaysOverdue int `json:"days_overdue"`
+	FineCents   int `json:"fine_cents"`
+}
+
+func accountResponse(userId int) fiber.Map {
+	var entries []models.AccountEntry
+
+	db.GetDB().Where("user_id = ?", userId).Order("created_at desc, id desc").Find(&entries)
+
+	policy := services.CurrentFinePolicy(db.GetDB())
+	balance := services.Balance(db.GetDB(), userId)
+
+	return fiber.Map{
+		"data":    entries,
+		"balance": balance,
+		"blocked": policy.BlockThresholdCents > 0 && balance > policy.BlockThresholdCents,
+	}
+}
+
+func GetMyAccount(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	return c.JSON(accountResponse(userId))
+}
+
+func GetPatronAccount(c *fiber.Ctx) error {
+
+	userId, err := strconv.Atoi(c.Params("userId"))
+
+	if err != nil || userId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	return c.JSON(accountResponse(userId))
+}
+
+func recordAccountCredit(c *fiber.Ctx, kind string) error {
+
+	userId, err := strconv.Atoi(c.Params("userId"))
+
+	if err != nil || userId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var request struct {
+		AmountCents int    `json:"amount_cents"`
+		LoanID      *int   `json:"loan_id"`
+		Note        string `json:"note"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if len(request.Note) > 255 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Note must be at most 255 characters",
+		})
+	}
+
+	entry, err := services.RecordPayment(userId, loggedUserID(c), kind, request.AmountCents, request.LoanID, request.Note)
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    "Account updated successfully",
+		"entry":   entry,
+		"balance": services.Balance(db.GetDB(), userId),
+	})
+}
+
+func RecordPatronPayment(c *fiber.Ctx) error {
+	return recordAccountCredit(c, models.EntryPayment)
+}
+
+func WaivePatronFine(c *fiber.Ctx) error {
+	return recordAccountCredit(c, models.EntryWaiver)
+}
+
+func GetOverdueLoans(c *fiber.Ctx) error {
+
+	now := time.Now()
+	policy := services.CurrentFinePolicy(db.GetDB())
+
+	var loans []models.Loan
+
+	db.GetDB().Where("returned_at IS NULL AND due_at < ?", now).Order("due_at").Find(&loans)
+
+	response := make([]overdueLoanResponse, 0, len(loans))
+
+	for _, loan := range loans {
+		response = append(response, overdueLoanResponse{
+			Loan:        loan,
+			DaysOverdue: int(now.Sub(loan.DueAt).Hours() / 24),
+			FineCents:   services.ComputeFine(policy, loan.DueAt, now),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": response,
+	})
+}
+
+func GetFinePolicy(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"data": services.CurrentFinePolicy(db.GetDB()),
+	})
+}
+
+func ModifyFinePolicy(c *fiber.Ctx) error {
+
+	policy := services.CurrentFinePolicy(db.GetDB())
+
+	if err := c.BodyParser(&policy); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if policy.PerDayCents < 0 || policy.GraceDays < 0 || policy.MaxFineCents < 0 || policy.BlockThresholdCents < 0 || policy.LostFeeCents < 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Fine policy values can't be negative",
+		})
+	}
+
+	policy, err := services.SaveFinePolicy(policy)
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to save fine policy",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   "Fine policy updated successfully",
+		"policy": policy,
+	})
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxGoalTarget = 1000000
+
+// goalsProgress works out the progress of one user's goals, periods follow
+// the user's timezone.
+func goalsProgress(goals []models.ReadingGoal) []services.GoalProgress {
+	now := time.Now()
+	result := make([]services.GoalProgress, 0, len(goals))
+
+	if len(goals) == 0 {
+		return result
+	}
+
+	loc := services.UserLocation(goals[0].UserID)
+
+	for _, goal := range goals {
+		result = append(result, services.Progress(goal, loc, now))
+	}
+
+	return result
+}
+
+func goalsQuery(c *fiber.Ctx, userId int) ([]models.ReadingGoal, bool) {
+	query := db.GetDB().Where("user_id = ?", userId)
+
+	if year := c.Query("year"); year != "" {
+		if _, err := strconv.Atoi(year); err != nil {
+			return nil, false
+		}
+
+		query = query.Where("year = ?", year)
+	}
+
+	var goals []models.ReadingGoal
+
+	query.Order("year desc, month, kind").Find(&goals)
+
+	return goals, true
+}
+
+func GetMyGoals(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	goals, ok := goalsQuery(c, userId)
+
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid year",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": goalsProgress(goals),
+	})
+}
+
+// GetUserGoals shows another reader's goals, only the ones they share and
+// only to their friends.
+func GetUserGoals(c *fiber.Ctx) error {
+
+	viewerId := loggedUserID(c)
+
+	userId, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || userId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if userId != viewerId && !services.AreFriends(userId, viewerId) {
+		return c.Status(403).JSON(fiber.Map{
+			"data": "Goals are only visible to friends",
+		})
+	}
+
+	goals, ok := goalsQuery(c, userId)
+
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid year",
+		})
+	}
+
+	if userId != viewerId {
+		shared := goals[:0]
+
+		for _, goal := range goals {
+			if goal.Shared {
+				shared = append(shared, goal)
+			}
+		}
+
+		goals = shared
+	}
+
+	return c.JSON(fiber.Map{
+		"data": goalsProgress(goals),
+	})
+}
+
+func CreateGoal(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var goal models.ReadingGoal
+
+	if err := c.BodyParser(&goal); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if goal.Kind == "" {
+		goal.Kind = models.GoalBooks
+	}
+
+	if goal.Kind != models.GoalBooks && goal.Kind != models.GoalPages {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Kind must be books or pages",
+		})
+	}
+
+	if goal.Year == 0 {
+		goal.Year = time.Now().Year()
+	}
+
+	if goal.Year < 1900 || goal.Year > time.Now().Year()+1 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid year",
+		})
+	}
+
+	if goal.Month < 0 || goal.Month > 12 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Month must be between 1 and 12, or 0 for the whole year",
+		})
+	}
+
+	if goal.Target < 1 || goal.Target > maxGoalTarget {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Target must be a positive number",
+		})
+	}
+
+	var existing models.ReadingGoal
+
+	db.GetDB().Where("user_id = ? AND year = ? AND month = ? AND kind = ?", userId, goal.Year, goal.Month, goal.Kind).First(&existing)
+
+	if existing.ID > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You already have this goal, update it instead",
+		})
+	}
+
+	goal.ID = 0
+	goal.UserID = userId
+
+	db.GetDB().Create(&goal)
+
+	return c.JSON(fiber.Map{
+		"data": "Goal created successfully",
+		"goal": goalsProgress([]models.ReadingGoal{goal})[0],
+	})
+}
+
+func findOwnedGoal(c *fiber.Ctx) (models.ReadingGoal, int, string) {
+	var goal models.ReadingGoal
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return goal, 401, "Unauthorized, please log in"
+	}
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&goal)
+
+	if goal.ID == 0 || goal.UserID != userId {
+		return goal, 404, "Goal not found"
+	}
+
+	return goal, 0, ""
+}
+
+func ModifyGoal(c *fiber.Ctx) error {
+
+	goal, status, message := findOwnedGoal(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var request struct {
+		Target *int  `json:"target"`
+		Shared *bool `json:"shared"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if request.Target != nil {
+		if *request.Target < 1 || *request.Target > maxGoalTarget {
+			return c.Status(400).JSON(fiber.Map{
+				"data": "Target must be a positive number",
+			})
+		}
+
+		goal.Target = *request.Target
+	}
+
+	if request.Shared != nil {
+		goal.Shared = *request.Shared
+	}
+
+	db.GetDB().Model(&goal).Updates(map[string]interface{}{
+		"target": goal.Target,
+		"shared": goal.Shared,
+	})
+
+	return c.JSON(fiber.Map{
+		"data": "Goal updated successfully",
+		"goal": goalsProgress([]models.ReadingGoal{goal})[0],
+	})
+}
+
+func DeleteGoal(c *fiber.Ctx) error {
+
+	goal, status, message := findOwnedGoal(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Delete(&goal)
+
+	return c.JSON(fiber.Map{
+		"data": "Goal deleted successfully",
+	})
+}
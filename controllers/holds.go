@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type holdResponse struct {
+	models.Hold
+	Position int64 `json:"position"`
+}
+
+func PlaceHold(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	bookId, err := strconv.Atoi(c.Params("bookId"))
+
+	if err != nil || bookId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	hold, err := services.PlaceHold(userId, bookId)
+
+	if err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Hold placed successfully",
+		"hold": holdResponse{Hold: hold, Position: services.HoldPosition(hold)},
+	})
+}
+
+func CancelHold(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	holdId, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || holdId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if _, err := services.CancelHold(holdId, userId); err != nil {
+		return circulationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Hold cancelled successfully",
+	})
+}
+
+func GetMyHolds(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var holds []models.Hold
+
+	db.GetDB().Where("user_id = ? AND status IN ?", userId, []string{models.HoldWaiting, models.HoldReady}).Order("created_at").Find(&holds)
+
+	response := make([]holdResponse, 0, len(holds))
+
+	for _, hold := range holds {
+		response = append(response, holdResponse{Hold: hold, Position: services.HoldPosition(hold)})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": response,
+	})
+}
+
+func GetHoldPosition(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var hold models.Hold
+
+	db.GetDB().Where("user_id = ? AND book_id = ? AND status IN ?", userId, c.Params("bookId"), []string{models.HoldWaiting, models.HoldReady}).First(&hold)
+
+	if hold.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "You don't have a hold on this book",
+		})
+	}
+
+	var queueLength int64
+
+	db.GetDB().Model(&models.Hold{}).Where("book_id = ? AND status = ?", hold.BookID, models.HoldWaiting).Count(&queueLength)
+
+	return c.JSON(fiber.Map{
+		"data":         holdResponse{Hold: hold, Position: services.HoldPosition(hold)},
+		"queue_length": queueLength,
+	})
+}
+
+func GetBookHoldQueue(c *fiber.Ctx) error {
+
+	var holds []models.Hold
+
+	db.GetDB().Where("book_id = ? AND status IN ?", c.Params("bookId"), []string{models.HoldWaiting, models.HoldReady}).Order("id").Find(&holds)
+
+	return c.JSON(fiber.Map{
+		"data": holds,
+	})
+}
+
+func GetNotifications(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	query := db.GetDB().Where("user_id = ?", userId)
+
+	if c.QueryBool("unread") {
+		query = query.Where("read = ?", false)
+	}
+
+	var notifications []models.Notification
+
+	query.Order("created_at desc").Limit(100).Find(&notifications)
+
+	return c.JSON(fiber.Map{
+		"data": notifications,
+	})
+}
+
+func MarkNotificationRead(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	query := db.GetDB().Model(&models.Notification{}).Where("user_id = ?", userId)
+
+	if id := c.Params("id"); id != "" {
+		query = query.Where("id = ?", id)
+	}
+
+	query.Update("read", true)
+
+	return c.JSON(fiber.Map{
+		"data": "Notifications marked as read",
+	})
+}
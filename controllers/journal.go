@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var journalMoods = map[string]bool{
+	"happy":      true,
+	"sad":        true,
+	"excited":    true,
+	"bored":      true,
+	"thoughtful": true,
+	"inspired":   true,
+	"confused":   true,
+	"angry":      true,
+	"relaxed":    true,
+}
+
+func applyJournalFields(entry *models.JournalEntry, request map[string]interface{}, book models.Book) string {
+	for key, value := range request {
+		switch key {
+		case "entry_date":
+			date, ok := value.(string)
+
+			if !ok {
+				return "Entry date must be formatted as YYYY-MM-DD"
+			}
+
+			parsed, err := time.Parse("2006-01-02", date)
+
+			if err != nil {
+				return "Entry date must be formatted as YYYY-MM-DD"
+			}
+
+			entry.EntryDate = parsed
+		case "mood":
+			mood, _ := value.(string)
+
+			if mood != "" && !journalMoods[mood] {
+				return "Unknown mood"
+			}
+
+			entry.Mood = mood
+		case "page":
+			if value == nil {
+				entry.Page = nil
+				continue
+			}
+
+			page, ok := value.(float64)
+
+			if !ok || page < 0 || (book.Pages > 0 && uint(page) > book.Pages) {
+				return "Invalid page"
+			}
+
+			p := uint(page)
+			entry.Page = &p
+		case "body":
+			body, _ := value.(string)
+			entry.Body = utils.SanitizeMarkdown(body)
+		case "shared":
+			entry.Shared, _ = value.(bool)
+		}
+	}
+
+	if len(entry.Body) == 0 || len(entry.Body) > 10000 {
+		return "Entry must be between 1 and 10000 characters"
+	}
+
+	return ""
+}
+
+func GetJournalEntries(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	userBooksId, err := strconv.Atoi(c.Params("userBooksId"))
+
+	if err != nil || userBooksId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", userBooksId).First(&userBook)
+
+	if userBook.UserBooksID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	query := db.GetDB().Where("user_books_id = ?", userBooksId)
+
+	if int(userBook.UserID) != userId {
+		query = query.Where("shared = ?", true)
+	}
+
+	var entries []models.JournalEntry
+
+	query.Order("entry_date desc, id desc").Find(&entries)
+
+	return c.JSON(fiber.Map{
+		"data": entries,
+	})
+}
+
+func CreateJournalEntry(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", request["user_books_id"]).First(&userBook)
+
+	if userBook.UserBooksID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	if int(userBook.UserID) != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", userBook.BookID).First(&book)
+
+	entry := models.JournalEntry{
+		UserBooksID: userBook.UserBooksID,
+		UserID:      userId,
+		BookID:      int(userBook.BookID),
+		EntryDate:   time.Now().UTC().Truncate(24 * time.Hour),
+	}
+
+	if message := applyJournalFields(&entry, request, book); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Create(&entry)
+
+	return c.JSON(fiber.Map{
+		"data":  "Journal entry created successfully",
+		"entry": entry,
+	})
+}
+
+func ModifyJournalEntry(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var entry models.JournalEntry
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&entry)
+
+	if entry.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Journal entry not found",
+		})
+	}
+
+	if entry.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", entry.BookID).First(&book)
+
+	if message := applyJournalFields(&entry, request, book); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Save(&entry)
+
+	return c.JSON(fiber.Map{
+		"data":  "Journal entry updated successfully",
+		"entry": entry,
+	})
+}
+
+func DeleteJournalEntry(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var entry models.JournalEntry
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&entry)
+
+	if entry.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Journal entry not found",
+		})
+	}
+
+	if entry.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	db.GetDB().Delete(&entry)
+
+	return c.JSON(fiber.Map{
+		"data": "Journal entry deleted successfully",
+	})
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/joho/godotenv"
+)
+
+const maxLabelsPerSheet = 240
+
+func bookPageURL(bookId int) string {
+	godotenv.Load()
+
+	frontend := os.Getenv("FRONTEND_URL")
+
+	if frontend == "" {
+		frontend = "http://localhost:3000"
+	}
+
+	return strings.TrimRight(frontend, "/") + "/books/" + strconv.Itoa(bookId)
+}
+
+func bookBarcodeContent(book models.Book) string {
+	isbn := strings.NewReplacer("-", "", " ", "").Replace(book.ISBN)
+
+	if isbn != "" {
+		return isbn
+	}
+
+	return "BOOK-" + strconv.Itoa(book.ID)
+}
+
+func sendSymbol(c *fiber.Ctx, symbol utils.Symbol) error {
+	switch c.Query("format", "png") {
+	case "svg":
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+		return c.Send(symbol.SVG())
+	case "png":
+		var buf bytes.Buffer
+
+		if err := symbol.WritePNG(&buf); err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"data": "Label can't be rendered",
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(buf.Bytes())
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Format must be png or svg",
+		})
+	}
+}
+
+func GetCopyBarcode(c *fiber.Ctx) error {
+
+	var bookCopy models.Copy
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&bookCopy)
+
+	if bookCopy.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Copy not found",
+		})
+	}
+
+	symbol, err := utils.Code128(bookCopy.Barcode)
+
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Barcode can't be encoded as Code 128",
+		})
+	}
+
+	return sendSymbol(c, symbol)
+}
+
+func GetBookBarcode(c *fiber.Ctx) error {
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Book not found",
+		})
+	}
+
+	symbol, err := utils.Code128(bookBarcodeContent(book))
+
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Barcode can't be encoded as Code 128",
+		})
+	}
+
+	return sendSymbol(c, symbol)
+}
+
+func GetBookQRCode(c *fiber.Ctx) error {
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Book not found",
+		})
+	}
+
+	symbol, err := utils.QRCode(bookPageURL(book.ID))
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "QR code can't be rendered",
+		})
+	}
+
+	return sendSymbol(c, symbol)
+}
+
+func GetLabelSheet(c *fiber.Ctx) error {
+
+	var request struct {
+		CopyIDs []int `json:"copy_ids"`
+		BookIDs []int `json:"book_ids"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	total := len(request.CopyIDs) + len(request.BookIDs)
+
+	if total == 0 || total > maxLabelsPerSheet {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Select between 1 and " + strconv.Itoa(maxLabelsPerSheet) + " copies or books",
+		})
+	}
+
+	var copies []models.Copy
+
+	if len(request.CopyIDs) > 0 {
+		db.GetDB().Where("id IN ?", request.CopyIDs).Order("id").Find(&copies)
+	}
+
+	bookIds := append([]int{}, request.BookIDs...)
+
+	for _, bookCopy := range copies {
+		bookIds = append(bookIds, bookCopy.BookID)
+	}
+
+	books := make(map[int]models.Book)
+
+	if len(bookIds) > 0 {
+		var found []models.Book
+
+		db.GetDB().Where("id IN ?", bookIds).Find(&found)
+
+		for _, book := range found {
+			books[book.ID] = book
+		}
+	}
+
+	labels := make([]utils.Label, 0, total)
+
+	for _, bookCopy := range copies {
+		book := books[bookCopy.BookID]
+
+		labels = append(labels, utils.Label{
+			Title:     book.Title,
+			Subtitle:  strings.TrimSpace(book.Author + "  " + bookCopy.ShelfLocation),
+			Barcode:   bookCopy.Barcode,
+			QRContent: bookPageURL(book.ID),
+		})
+	}
+
+	for _, id := range request.BookIDs {
+		book, ok := books[id]
+
+		if !ok {
+			continue
+		}
+
+		labels = append(labels, utils.Label{
+			Title:     book.Title,
+			Subtitle:  book.Author,
+			Barcode:   bookBarcodeContent(book),
+			QRContent: bookPageURL(book.ID),
+		})
+	}
+
+	if len(labels) == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "None of the selected copies or books exist",
+		})
+	}
+
+	pdf, err := utils.LabelSheetPDF(labels)
+
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Labels can't be rendered",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, "attachment; filename=\"labels.pdf\"")
+
+	return c.Send(pdf)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+const leaderboardPageSize = 50
+
+// GetLeaderboard ranks readers by ?metric=pages|books over the current
+// ?period=week|month|year, among everyone or only the viewer's friends
+// (?scope=global|friends).
+func GetLeaderboard(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	metric := c.Query("metric", "pages")
+	period := c.Query("period", models.PeriodWeek)
+	scope := c.Query("scope", "global")
+
+	if _, ok := services.LeaderboardMetrics[metric]; !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Metric must be pages or books",
+		})
+	}
+
+	if !services.ValidLeaderboardPeriod(period) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Period must be week, month or year",
+		})
+	}
+
+	var userIds []int
+
+	switch scope {
+	case "global":
+	case "friends":
+		userIds = append(services.FriendIDs(userId), userId)
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Scope must be global or friends",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	rows := services.Leaderboard(metric, period, userIds, (page-1)*leaderboardPageSize, leaderboardPageSize+1)
+
+	hasMore := len(rows) > leaderboardPageSize
+
+	if hasMore {
+		rows = rows[:leaderboardPageSize]
+	}
+
+	return c.JSON(fiber.Map{
+		"data":         rows,
+		"me":           services.LeaderboardPosition(metric, period, userIds, userId),
+		"metric":       metric,
+		"period":       period,
+		"scope":        scope,
+		"period_start": services.PeriodStart(period, time.Now()),
+		"page":         page,
+		"hasMore":      hasMore,
+	})
+}
+
+func SetLeaderboardOptOut(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var request struct {
+		OptOut *bool `json:"opt_out"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.OptOut == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	db.GetDB().Model(&models.User{}).Where("id = ?", userId).Update("leaderboard_opt_out", *request.OptOut)
+
+	message := "You are back on the leaderboards"
+
+	if *request.OptOut {
+		message = "You no longer appear on leaderboards"
+	}
+
+	return c.JSON(fiber.Map{
+		"data":                message,
+		"leaderboard_opt_out": *request.OptOut,
+	})
+}
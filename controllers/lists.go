@@ -0,0 +1,679 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const listsPageSize = 10
+
+var listVisibilities = map[string]bool{
+	"public":  true,
+	"friends": true,
+	"private": true,
+}
+
+var (
+	errRelationExists  = errors.New("relation already exists")
+	errRelationMissing = errors.New("relation doesn't exist")
+)
+
+type listItemResponse struct {
+	models.BookListItem
+	Book models.Book `json:"book"`
+}
+
+func canViewList(list models.BookList, viewerId int) bool {
+	switch list.Visibility {
+	case "public":
+		return true
+	case "friends":
+		return list.UserID == viewerId || services.AreFriends(list.UserID, viewerId)
+	default:
+		return list.UserID == viewerId
+	}
+}
+
+// visibleListsQuery narrows a book_lists query down to the lists the viewer
+// is allowed to see: public ones, their own and their friends' shared lists.
+func visibleListsQuery(query *gorm.DB, viewerId int) *gorm.DB {
+	if viewerId == 0 {
+		return query.Where("book_lists.visibility = ?", "public")
+	}
+
+	friendIds := services.FriendIDs(viewerId)
+
+	if len(friendIds) == 0 {
+		return query.Where("book_lists.visibility = ? OR book_lists.user_id = ?", "public", viewerId)
+	}
+
+	return query.Where("book_lists.visibility = ? OR book_lists.user_id = ? OR (book_lists.visibility = ? AND book_lists.user_id IN ?)", "public", viewerId, "friends", friendIds)
+}
+
+func findOwnedList(id string, userId int) (models.BookList, int, string) {
+	var list models.BookList
+
+	db.GetDB().Where("id = ?", id).First(&list)
+
+	if list.ID == 0 {
+		return list, 404, "List not found"
+	}
+
+	if list.UserID != userId {
+		return list, 401, "Unauthorized"
+	}
+
+	return list, 0, ""
+}
+
+func applyListFields(list *models.BookList, request map[string]interface{}) string {
+	for key, value := range request {
+		switch key {
+		case "name":
+			list.Name, _ = value.(string)
+			list.Name = strings.TrimSpace(list.Name)
+		case "description":
+			list.Description, _ = value.(string)
+		case "visibility":
+			list.Visibility, _ = value.(string)
+		}
+	}
+
+	if len(list.Name) < 3 || len(list.Name) > 100 {
+		return "Name must be between 3 and 100 characters"
+	}
+
+	if len(list.Description) > 1000 {
+		return "Description must be at most 1000 characters"
+	}
+
+	if !listVisibilities[list.Visibility] {
+		return "Visibility must be public, friends or private"
+	}
+
+	return ""
+}
+
+func GetPopularLists(c *fiber.Ctx) error {
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	var lists []models.BookList
+
+	db.GetDB().Where("visibility = ?", "public").
+		Order("likes_count + followers_count desc, updated_at desc").
+		Offset((page - 1) * listsPageSize).
+		Limit(listsPageSize + 1).
+		Find(&lists)
+
+	hasMore := len(lists) > listsPageSize
+
+	if hasMore {
+		lists = lists[:listsPageSize]
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    lists,
+		"hasMore": hasMore,
+	})
+}
+
+func GetListsForBook(c *fiber.Ctx) error {
+
+	bookId, err := strconv.Atoi(c.Params("bookId"))
+
+	if err != nil || bookId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var lists []models.BookList
+
+	query := db.GetDB().Model(&models.BookList{}).
+		Joins("join book_list_items on book_list_items.list_id = book_lists.id").
+		Where("book_list_items.book_id = ?", bookId)
+
+	visibleListsQuery(query, loggedUserID(c)).
+		Distinct("book_lists.*").
+		Order("book_lists.likes_count desc").
+		Find(&lists)
+
+	return c.JSON(fiber.Map{
+		"data": lists,
+	})
+}
+
+func GetUserLists(c *fiber.Ctx) error {
+
+	userId, err := strconv.Atoi(c.Params("userId"))
+
+	if err != nil || userId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var lists []models.BookList
+
+	query := db.GetDB().Model(&models.BookList{}).Where("book_lists.user_id = ?", userId)
+
+	visibleListsQuery(query, loggedUserID(c)).Order("updated_at desc").Find(&lists)
+
+	return c.JSON(fiber.Map{
+		"data": lists,
+	})
+}
+
+func GetFollowedLists(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var lists []models.BookList
+
+	query := db.GetDB().Model(&models.BookList{}).
+		Joins("join book_list_follows on book_list_follows.list_id = book_lists.id").
+		Where("book_list_follows.user_id = ?", userId)
+
+	visibleListsQuery(query, userId).Order("book_lists.updated_at desc").Find(&lists)
+
+	return c.JSON(fiber.Map{
+		"data": lists,
+	})
+}
+
+func GetList(c *fiber.Ctx) error {
+
+	var list models.BookList
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&list)
+
+	if list.ID == 0 || !canViewList(list, loggedUserID(c)) {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "List not found",
+		})
+	}
+
+	var items []models.BookListItem
+
+	db.GetDB().Where("list_id = ?", list.ID).Order("position, id").Find(&items)
+
+	bookIds := make([]int, 0, len(items))
+
+	for _, item := range items {
+		bookIds = append(bookIds, item.BookID)
+	}
+
+	books := make(map[int]models.Book, len(items))
+
+	if len(bookIds) > 0 {
+		var found []models.Book
+
+		db.GetDB().Where("id IN ?", bookIds).Find(&found)
+
+		for _, book := range found {
+			books[book.ID] = book
+		}
+	}
+
+	responseItems := make([]listItemResponse, 0, len(items))
+
+	for _, item := range items {
+		if book, ok := books[item.BookID]; ok {
+			responseItems = append(responseItems, listItemResponse{BookListItem: item, Book: book})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  list,
+		"items": responseItems,
+	})
+}
+
+func CreateList(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	list := models.BookList{
+		UserID:     userId,
+		Visibility: "public",
+	}
+
+	if message := applyListFields(&list, request); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Create(&list)
+
+	return c.JSON(fiber.Map{
+		"data": "List created successfully",
+		"list": list,
+	})
+}
+
+func ModifyList(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	list, status, message := findOwnedList(c.Params("id"), userId)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if message := applyListFields(&list, request); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Save(&list)
+
+	return c.JSON(fiber.Map{
+		"data": "List updated successfully",
+		"list": list,
+	})
+}
+
+func DeleteList(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	list, status, message := findOwnedList(c.Params("id"), userId)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Transaction(func(tx *gorm.DB) error {
+		tx.Where("list_id = ?", list.ID).Delete(&models.BookListItem{})
+		tx.Where("list_id = ?", list.ID).Delete(&models.BookListLike{})
+		tx.Where("list_id = ?", list.ID).Delete(&models.BookListFollow{})
+
+		return tx.Delete(&list).Error
+	})
+
+	return c.JSON(fiber.Map{
+		"data": "List deleted successfully",
+	})
+}
+
+func AddListItem(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	list, status, message := findOwnedList(c.Params("id"), userId)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var item models.BookListItem
+
+	if err := c.BodyParser(&item); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if len(item.Comment) > 1000 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Comment must be at most 1000 characters",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", item.BookID).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Book doesn't exist",
+		})
+	}
+
+	var existing models.BookListItem
+
+	db.GetDB().Where("list_id = ? AND book_id = ?", list.ID, book.ID).First(&existing)
+
+	if existing.ID > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Book is already in this list",
+		})
+	}
+
+	var lastPosition int
+
+	db.GetDB().Model(&models.BookListItem{}).Where("list_id = ?", list.ID).Select("coalesce(max(position), 0)").Scan(&lastPosition)
+
+	item.ID = 0
+	item.ListID = list.ID
+	item.Position = lastPosition + 1
+
+	db.GetDB().Create(&item)
+	db.GetDB().Model(&list).Update("updated_at", item.CreatedAt)
+
+	return c.JSON(fiber.Map{
+		"data": "Book added to list",
+		"item": item,
+	})
+}
+
+func ModifyListItem(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	list, status, message := findOwnedList(c.Params("id"), userId)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var item models.BookListItem
+
+	db.GetDB().Where("id = ? AND list_id = ?", c.Params("itemId"), list.ID).First(&item)
+
+	if item.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Item not found",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	comment, ok := request["comment"].(string)
+
+	if !ok || len(comment) > 1000 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Comment must be at most 1000 characters",
+		})
+	}
+
+	item.Comment = comment
+
+	db.GetDB().Save(&item)
+
+	return c.JSON(fiber.Map{
+		"data": "Item updated successfully",
+		"item": item,
+	})
+}
+
+func DeleteListItem(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	list, status, message := findOwnedList(c.Params("id"), userId)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	result := db.GetDB().Where("id = ? AND list_id = ?", c.Params("itemId"), list.ID).Delete(&models.BookListItem{})
+
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Item not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Book removed from list",
+	})
+}
+
+func ReorderList(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	list, status, message := findOwnedList(c.Params("id"), userId)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var request struct {
+		ItemIDs []int `json:"item_ids"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var items []models.BookListItem
+
+	db.GetDB().Where("list_id = ?", list.ID).Find(&items)
+
+	if len(request.ItemIDs) != len(items) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "The new order must contain every item of the list exactly once",
+		})
+	}
+
+	known := make(map[int]bool, len(items))
+
+	for _, item := range items {
+		known[item.ID] = true
+	}
+
+	for _, id := range request.ItemIDs {
+		if !known[id] {
+			return c.Status(400).JSON(fiber.Map{
+				"data": "The new order must contain every item of the list exactly once",
+			})
+		}
+
+		delete(known, id)
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		for position, id := range request.ItemIDs {
+			if err := tx.Model(&models.BookListItem{}).Where("id = ?", id).Update("position", position+1).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to reorder list",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "List reordered successfully",
+	})
+}
+
+// changeListRelation adds or removes a like/follow row and keeps the
+// denormalized counter on the list in sync inside the same transaction.
+func changeListRelation(c *fiber.Ctx, relation interface{}, counter string, add bool) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var list models.BookList
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&list)
+
+	if list.ID == 0 || !canViewList(list, userId) {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "List not found",
+		})
+	}
+
+	if list.UserID == userId {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You can't do this on your own list",
+		})
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+
+		if add {
+			result = tx.Where(map[string]interface{}{"list_id": list.ID, "user_id": userId}).FirstOrCreate(relation)
+
+			if result.Error == nil && result.RowsAffected == 0 {
+				return errRelationExists
+			}
+		} else {
+			result = tx.Where("list_id = ? AND user_id = ?", list.ID, userId).Delete(relation)
+
+			if result.Error == nil && result.RowsAffected == 0 {
+				return errRelationMissing
+			}
+		}
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		expression := gorm.Expr(counter + " + 1")
+
+		if !add {
+			expression = gorm.Expr("greatest(" + counter + " - 1, 0)")
+		}
+
+		return tx.Model(&list).UpdateColumn(counter, expression).Error
+	})
+
+	switch err {
+	case nil:
+		return c.JSON(fiber.Map{
+			"data": "List updated successfully",
+		})
+	case errRelationExists:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Already done",
+		})
+	case errRelationMissing:
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Nothing to undo",
+		})
+	default:
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to update list",
+		})
+	}
+}
+
+func LikeList(c *fiber.Ctx) error {
+	return changeListRelation(c, &models.BookListLike{}, "likes_count", true)
+}
+
+func UnlikeList(c *fiber.Ctx) error {
+	return changeListRelation(c, &models.BookListLike{}, "likes_count", false)
+}
+
+func FollowList(c *fiber.Ctx) error {
+	return changeListRelation(c, &models.BookListFollow{}, "followers_count", true)
+}
+
+func UnfollowList(c *fiber.Ctx) error {
+	return changeListRelation(c, &models.BookListFollow{}, "followers_count", false)
+}
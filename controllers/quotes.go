@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const quotesPageSize = 20
+
+type quoteResponse struct {
+	models.Quote
+	UserName string `json:"user_name"`
+}
+
+func validateQuote(quote models.Quote, book models.Book) string {
+	if len(strings.TrimSpace(quote.Text)) == 0 || len(quote.Text) > 2000 {
+		return "Quote must be between 1 and 2000 characters"
+	}
+
+	if len(quote.Note) > 1000 {
+		return "Note must be at most 1000 characters"
+	}
+
+	if book.Pages > 0 && quote.Page > book.Pages {
+		return "Page is greater than the number of pages of the book"
+	}
+
+	return ""
+}
+
+func CreateQuote(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var quote models.Quote
+
+	if err := c.BodyParser(&quote); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", quote.UserBooksID).First(&userBook)
+
+	if userBook.UserBooksID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	if int(userBook.UserID) != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", userBook.BookID).First(&book)
+
+	if message := validateQuote(quote, book); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	quote.ID = 0
+	quote.UserID = userId
+	quote.BookID = int(userBook.BookID)
+
+	db.GetDB().Create(&quote)
+
+	return c.JSON(fiber.Map{
+		"data":  "Quote saved successfully",
+		"quote": quote,
+	})
+}
+
+func ModifyQuote(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var quote models.Quote
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&quote)
+
+	if quote.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Quote not found",
+		})
+	}
+
+	if quote.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	for key, value := range request {
+		switch key {
+		case "text":
+			quote.Text, _ = value.(string)
+		case "note":
+			quote.Note, _ = value.(string)
+		case "page":
+			page, _ := value.(float64)
+			quote.Page = uint(page)
+		case "public":
+			quote.Public, _ = value.(bool)
+		}
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", quote.BookID).First(&book)
+
+	if message := validateQuote(quote, book); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Save(&quote)
+
+	return c.JSON(fiber.Map{
+		"data":  "Quote updated successfully",
+		"quote": quote,
+	})
+}
+
+func DeleteQuote(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var quote models.Quote
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&quote)
+
+	if quote.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Quote not found",
+		})
+	}
+
+	if quote.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	db.GetDB().Delete(&quote)
+
+	return c.JSON(fiber.Map{
+		"data": "Quote deleted successfully",
+	})
+}
+
+func GetMyQuotes(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	query := db.GetDB().Where("user_id = ?", userId)
+
+	if bookId := c.Query("book_id"); bookId != "" {
+		query = query.Where("book_id = ?", bookId)
+	}
+
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("text ILIKE ? OR note ILIKE ?", pattern, pattern)
+	}
+
+	var quotes []models.Quote
+
+	query.Order("book_id, page, id").Find(&quotes)
+
+	return c.JSON(fiber.Map{
+		"data": quotes,
+	})
+}
+
+func GetBookQuotes(c *fiber.Ctx) error {
+
+	bookId, err := strconv.Atoi(c.Params("bookId"))
+
+	if err != nil || bookId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	var total int64
+
+	db.GetDB().Model(&models.Quote{}).Where("book_id = ? AND public = ?", bookId, true).Count(&total)
+
+	var quotes []quoteResponse
+
+	db.GetDB().Model(&models.Quote{}).
+		Select("quotes.*, users.name as user_name").
+		Joins("left join users on users.id = quotes.user_id").
+		Where("quotes.book_id = ? AND quotes.public = ?", bookId, true).
+		Order("quotes.created_at desc").
+		Offset((page - 1) * quotesPageSize).
+		Limit(quotesPageSize).
+		Scan(&quotes)
+
+	return c.JSON(fiber.Map{
+		"data":    quotes,
+		"hasMore": int(total) > page*quotesPageSize,
+	})
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
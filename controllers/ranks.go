@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetRanks(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"data": services.RankDefinitions(),
+	})
+}
+
+func validRank(rank *models.RankDefinition) string {
+	rank.Name = strings.TrimSpace(rank.Name)
+
+	if len(rank.Name) < 2 || len(rank.Name) > 20 {
+		return "Name must be between 2 and 20 characters"
+	}
+
+	if len(rank.Icon) > 255 {
+		return "Icon must be at most 255 characters"
+	}
+
+	if rank.MinBooksFinished < 0 || rank.MinPagesRead < 0 || rank.MinStreakDays < 0 {
+		return "Rank criteria can't be negative"
+	}
+
+	var existing models.RankDefinition
+
+	db.GetDB().Where("LOWER(name) = LOWER(?) AND id <> ?", rank.Name, rank.ID).First(&existing)
+
+	if existing.ID > 0 {
+		return "A rank with this name already exists"
+	}
+
+	return ""
+}
+
+func CreateRank(c *fiber.Ctx) error {
+
+	var rank models.RankDefinition
+
+	if err := c.BodyParser(&rank); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	rank.ID = 0
+
+	if message := validRank(&rank); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Create(&rank)
+
+	go services.EvaluateAllRanks(true)
+
+	return c.JSON(fiber.Map{
+		"data": "Rank created successfully",
+		"rank": rank,
+	})
+}
+
+func ModifyRank(c *fiber.Ctx) error {
+
+	var rank models.RankDefinition
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&rank)
+
+	if rank.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Rank not found",
+		})
+	}
+
+	id := rank.ID
+	previousName := rank.Name
+
+	if err := c.BodyParser(&rank); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	rank.ID = id
+
+	if message := validRank(&rank); message != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	db.GetDB().Model(&rank).Updates(map[string]interface{}{
+		"name":               rank.Name,
+		"icon":               rank.Icon,
+		"level":              rank.Level,
+		"min_books_finished": rank.MinBooksFinished,
+		"min_pages_read":     rank.MinPagesRead,
+		"min_streak_days":    rank.MinStreakDays,
+	})
+
+	// a renamed rank is not a promotion, users holding it just follow along
+	db.GetDB().Model(&models.User{}).Where("rank = ?", previousName).Update("rank", rank.Name)
+
+	go services.EvaluateAllRanks(true)
+
+	return c.JSON(fiber.Map{
+		"data": "Rank updated successfully",
+		"rank": rank,
+	})
+}
+
+func DeleteRank(c *fiber.Ctx) error {
+
+	var rank models.RankDefinition
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&rank)
+
+	if rank.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Rank not found",
+		})
+	}
+
+	db.GetDB().Model(&models.RankHistory{}).Where("rank_id = ?", rank.ID).Update("rank_id", nil)
+	db.GetDB().Delete(&rank)
+
+	go services.EvaluateAllRanks(true)
+
+	return c.JSON(fiber.Map{
+		"data": "Rank deleted successfully",
+	})
+}
+
+// GetUserRank shows a user's rank, what it is based on, the next rank to
+// reach and the history of rank changes.
+func GetUserRank(c *fiber.Ctx) error {
+
+	userId, status, message := activityUserID(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var user models.User
+
+	db.GetDB().Where("id = ?", userId).First(&user)
+
+	if user.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User not found",
+		})
+	}
+
+	ranks := services.RankDefinitions()
+	metrics := services.UserRankMetrics(userId)
+	current := services.MatchRank(ranks, metrics)
+
+	// ranks are sorted from the highest, the next one is the lowest above
+	var next *models.RankDefinition
+
+	for i := range ranks {
+		if current != nil && ranks[i].Level <= current.Level {
+			break
+		}
+
+		next = &ranks[i]
+	}
+
+	var history []models.RankHistory
+
+	db.GetDB().Where("user_id = ?", userId).Order("created_at desc, id desc").Find(&history)
+
+	return c.JSON(fiber.Map{
+		"data":    current,
+		"rank":    user.Rank,
+		"metrics": metrics,
+		"next":    next,
+		"history": history,
+	})
+}
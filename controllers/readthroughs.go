@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func GetReadThroughs(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", c.Params("userBooksId")).First(&userBook)
+
+	if userBook.UserBooksID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	// dates and ratings of each read are shared with friends, like sessions
+	if int(userBook.UserID) != userId && !services.AreFriends(int(userBook.UserID), userId) {
+		return c.Status(403).JSON(fiber.Map{
+			"data": "Read-throughs are only visible to friends",
+		})
+	}
+
+	var readThroughs []models.ReadThrough
+
+	db.GetDB().Where("user_books_id = ?", userBook.UserBooksID).Order("number").Find(&readThroughs)
+
+	var finished int
+
+	for _, readThrough := range readThroughs {
+		if readThrough.State == models.StateFinished {
+			finished++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     readThroughs,
+		"current":  userBook.CurrentReadThroughID,
+		"finished": finished,
+	})
+}
+
+func RereadUserBook(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var request struct {
+		UserBooksID int `json:"user_books_id"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.UserBooksID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var userBook models.UserBooks
+
+	db.GetDB().Where("user_books_id = ?", request.UserBooksID).First(&userBook)
+
+	if userBook.UserBooksID == 0 || int(userBook.UserID) != userId {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User book not found",
+		})
+	}
+
+	var readThrough models.ReadThrough
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		readThrough, err = services.StartReread(tx, &userBook, time.Now())
+
+		return err
+	})
+
+	if err == services.ErrStillReading {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Finish or abandon the current read-through first",
+		})
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to start a new read-through",
+		})
+	}
+
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data":         "Started a new read-through",
+		"read_through": readThrough,
+	})
+}
+
+func RateReadThrough(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || id == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var readThrough models.ReadThrough
+
+	db.GetDB().Where("id = ?", id).First(&readThrough)
+
+	if readThrough.ID == 0 || readThrough.UserID != userId {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Read-through not found",
+		})
+	}
+
+	var request struct {
+		Rating *float64 `json:"rating"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	// a null rating clears it
+	if request.Rating != nil && !validRating(*request.Rating) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Rating must be between 1 and 5, in steps of 0.5",
+		})
+	}
+
+	readThrough.Rating = request.Rating
+
+	db.GetDB().Model(&readThrough).Update("rating", readThrough.Rating)
+
+	return c.JSON(fiber.Map{
+		"data":         "Read-through rated successfully",
+		"read_through": readThrough,
+	})
+}
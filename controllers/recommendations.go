@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxRecommendations = 50
+
+func recommendationLimit(c *fiber.Ctx) int {
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+
+	if err != nil || limit < 1 {
+		return 10
+	}
+
+	if limit > maxRecommendations {
+		return maxRecommendations
+	}
+
+	return limit
+}
+
+func GetSimilarBooks(c *fiber.Ctx) error {
+
+	id, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || id == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", id).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Book not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": services.SimilarBooks(book.ID, recommendationLimit(c)),
+	})
+}
+
+func GetRecommendations(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": services.RecommendForUser(userId, recommendationLimit(c)),
+	})
+}
@@ -0,0 +1,672 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/catalinfl/readit-api/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const reviewsPageSize = 10
+
+var (
+	errAlreadyVoted = errors.New("already voted")
+	errNotVoted     = errors.New("not voted")
+)
+
+type ratingSummary struct {
+	Average   float64          `json:"average"`
+	Count     int64            `json:"count"`
+	Histogram map[string]int64 `json:"histogram"`
+}
+
+type reviewResponse struct {
+	models.Review
+	UserName    string `json:"user_name"`
+	HasSpoilers bool   `json:"has_spoilers"`
+}
+
+type commentResponse struct {
+	models.ReviewComment
+	UserName    string             `json:"user_name"`
+	HasSpoilers bool               `json:"has_spoilers"`
+	Replies     []*commentResponse `json:"replies"`
+}
+
+func validRating(rating float64) bool {
+	return rating >= 1 && rating <= 5 && rating*2 == math.Trunc(rating*2)
+}
+
+func formatRating(rating float64) string {
+	return strconv.FormatFloat(rating, 'f', 1, 64)
+}
+
+func bookRatingSummary(bookId int) ratingSummary {
+	summary := ratingSummary{
+		Histogram: make(map[string]int64),
+	}
+
+	for r := 1.0; r <= 5; r += 0.5 {
+		summary.Histogram[formatRating(r)] = 0
+	}
+
+	type bucket struct {
+		Rating float64
+		Total  int64
+	}
+
+	var buckets []bucket
+
+	db.GetDB().Model(&models.Review{}).
+		Select("rating, count(*) as total").
+		Where("book_id = ?", bookId).
+		Group("rating").
+		Scan(&buckets)
+
+	var sum float64
+
+	for _, b := range buckets {
+		summary.Histogram[formatRating(b.Rating)] = b.Total
+		summary.Count += b.Total
+		sum += b.Rating * float64(b.Total)
+	}
+
+	if summary.Count > 0 {
+		summary.Average = math.Round(sum/float64(summary.Count)*100) / 100
+	}
+
+	return summary
+}
+
+func GetBookReviews(c *fiber.Ctx) error {
+
+	bookId, err := strconv.Atoi(c.Params("bookId"))
+
+	if err != nil || bookId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	order := "reviews.created_at desc"
+
+	switch c.Query("sort", "newest") {
+	case "newest":
+	case "helpful":
+		order = "reviews.helpful_count desc, reviews.created_at desc"
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Sort must be newest or helpful",
+		})
+	}
+
+	var total int64
+
+	db.GetDB().Model(&models.Review{}).Where("book_id = ?", bookId).Count(&total)
+
+	var reviews []reviewResponse
+
+	db.GetDB().Model(&models.Review{}).
+		Select("reviews.*, users.name as user_name").
+		Joins("left join users on users.id = reviews.user_id").
+		Where("reviews.book_id = ?", bookId).
+		Order(order).
+		Offset((page - 1) * reviewsPageSize).
+		Limit(reviewsPageSize).
+		Scan(&reviews)
+
+	showSpoilers := c.QueryBool("spoilers")
+
+	for i := range reviews {
+		reviews[i].HasSpoilers = utils.HasSpoilers(reviews[i].Text)
+
+		if !showSpoilers {
+			reviews[i].Text = utils.HideSpoilers(reviews[i].Text)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    reviews,
+		"hasMore": int(total) > page*reviewsPageSize,
+	})
+}
+
+func CreateReview(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	bookId, err := strconv.Atoi(c.Params("bookId"))
+
+	if err != nil || bookId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var review models.Review
+
+	if err := c.BodyParser(&review); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if !validRating(review.Rating) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Rating must be between 1 and 5, in steps of 0.5",
+		})
+	}
+
+	if len(review.Text) > 5000 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Review must be at most 5000 characters",
+		})
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("id = ?", bookId).First(&book)
+
+	if book.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Book not found",
+		})
+	}
+
+	var existingReview models.Review
+
+	db.GetDB().Where("user_id = ? AND book_id = ?", userId, bookId).First(&existingReview)
+
+	if existingReview.ID > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You already reviewed this book",
+		})
+	}
+
+	review.ID = 0
+	review.UserID = userId
+	review.BookID = bookId
+	review.HelpfulCount = 0
+
+	db.GetDB().Create(&review)
+
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data":   "Review created successfully",
+		"review": review,
+	})
+}
+
+func ModifyReview(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	id := c.Params("id")
+
+	if id == "" || id == "0" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var review models.Review
+
+	db.GetDB().Where("id = ?", id).First(&review)
+
+	if review.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Review not found",
+		})
+	}
+
+	if review.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	for key, value := range request {
+		switch key {
+		case "rating":
+			rating, ok := value.(float64)
+
+			if !ok || !validRating(rating) {
+				return c.Status(400).JSON(fiber.Map{
+					"data": "Rating must be between 1 and 5, in steps of 0.5",
+				})
+			}
+
+			review.Rating = rating
+		case "text":
+			text, ok := value.(string)
+
+			if !ok || len(text) > 5000 {
+				return c.Status(400).JSON(fiber.Map{
+					"data": "Review must be at most 5000 characters",
+				})
+			}
+
+			review.Text = text
+		}
+	}
+
+	db.GetDB().Save(&review)
+
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data":   "Review updated successfully",
+		"review": review,
+	})
+}
+
+func DeleteReview(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	id := c.Params("id")
+
+	if id == "" || id == "0" {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var review models.Review
+
+	db.GetDB().Where("id = ?", id).First(&review)
+
+	if review.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Review not found",
+		})
+	}
+
+	if review.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	db.GetDB().Where("review_id = ?", review.ID).Delete(&models.ReviewComment{})
+	db.GetDB().Where("review_id = ?", review.ID).Delete(&models.ReviewVote{})
+	db.GetDB().Delete(&review)
+
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data": "Review deleted successfully",
+	})
+}
+
+func GetReviewComments(c *fiber.Ctx) error {
+
+	reviewId, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || reviewId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var review models.Review
+
+	db.GetDB().Where("id = ?", reviewId).First(&review)
+
+	if review.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Review not found",
+		})
+	}
+
+	var comments []*commentResponse
+
+	db.GetDB().Model(&models.ReviewComment{}).
+		Select("review_comments.*, users.name as user_name").
+		Joins("left join users on users.id = review_comments.user_id").
+		Where("review_comments.review_id = ?", reviewId).
+		Order("review_comments.created_at, review_comments.id").
+		Scan(&comments)
+
+	showSpoilers := c.QueryBool("spoilers")
+
+	byId := make(map[int]*commentResponse, len(comments))
+
+	for _, comment := range comments {
+		comment.Replies = []*commentResponse{}
+		comment.HasSpoilers = utils.HasSpoilers(comment.Text)
+
+		if !showSpoilers {
+			comment.Text = utils.HideSpoilers(comment.Text)
+		}
+
+		byId[comment.ID] = comment
+	}
+
+	thread := []*commentResponse{}
+
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			if parent, ok := byId[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, comment)
+				continue
+			}
+		}
+
+		thread = append(thread, comment)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": thread,
+	})
+}
+
+func CreateReviewComment(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	reviewId, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || reviewId == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	var comment models.ReviewComment
+
+	if err := c.BodyParser(&comment); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	if len(comment.Text) == 0 || len(comment.Text) > 2000 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Comment must be between 1 and 2000 characters",
+		})
+	}
+
+	var review models.Review
+
+	db.GetDB().Where("id = ?", reviewId).First(&review)
+
+	if review.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Review not found",
+		})
+	}
+
+	if comment.ParentID != nil {
+		var parent models.ReviewComment
+
+		db.GetDB().Where("id = ? AND review_id = ?", *comment.ParentID, reviewId).First(&parent)
+
+		if parent.ID == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"data": "Parent comment doesn't belong to this review",
+			})
+		}
+	}
+
+	comment.ID = 0
+	comment.ReviewID = reviewId
+	comment.UserID = userId
+	comment.Deleted = false
+
+	db.GetDB().Create(&comment)
+
+	return c.JSON(fiber.Map{
+		"data":    "Comment created successfully",
+		"comment": comment,
+	})
+}
+
+func ModifyReviewComment(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var comment models.ReviewComment
+
+	db.GetDB().Where("id = ?", c.Params("commentId")).First(&comment)
+
+	if comment.ID == 0 || comment.Deleted {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Comment not found",
+		})
+	}
+
+	if comment.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	request := make(map[string]interface{})
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	text, ok := request["text"].(string)
+
+	if !ok || len(text) == 0 || len(text) > 2000 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Comment must be between 1 and 2000 characters",
+		})
+	}
+
+	comment.Text = text
+
+	db.GetDB().Save(&comment)
+
+	return c.JSON(fiber.Map{
+		"data":    "Comment updated successfully",
+		"comment": comment,
+	})
+}
+
+func DeleteReviewComment(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var comment models.ReviewComment
+
+	db.GetDB().Where("id = ?", c.Params("commentId")).First(&comment)
+
+	if comment.ID == 0 || comment.Deleted {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Comment not found",
+		})
+	}
+
+	if comment.UserID != userId {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized",
+		})
+	}
+
+	var replies int64
+
+	db.GetDB().Model(&models.ReviewComment{}).Where("parent_id = ?", comment.ID).Count(&replies)
+
+	// keep the node when it has replies so the rest of the thread stays attached
+	if replies > 0 {
+		comment.Text = ""
+		comment.Deleted = true
+		db.GetDB().Save(&comment)
+	} else {
+		db.GetDB().Delete(&comment)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Comment deleted successfully",
+	})
+}
+
+func VoteReviewHelpful(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var review models.Review
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&review)
+
+	if review.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Review not found",
+		})
+	}
+
+	if review.UserID == userId {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You can't vote your own review",
+		})
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Where(models.ReviewVote{ReviewID: review.ID, UserID: userId}).FirstOrCreate(&models.ReviewVote{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errAlreadyVoted
+		}
+
+		return tx.Model(&review).UpdateColumn("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+
+	if err == errAlreadyVoted {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "You already marked this review as helpful",
+		})
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Vote couldn't be saved",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Review marked as helpful",
+	})
+}
+
+func UnvoteReviewHelpful(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var review models.Review
+
+	db.GetDB().Where("id = ?", c.Params("id")).First(&review)
+
+	if review.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "Review not found",
+		})
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", review.ID, userId).Delete(&models.ReviewVote{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errNotVoted
+		}
+
+		return tx.Model(&review).UpdateColumn("helpful_count", gorm.Expr("greatest(helpful_count - 1, 0)")).Error
+	})
+
+	if err == errNotVoted {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "You didn't mark this review as helpful",
+		})
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Vote couldn't be removed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": "Helpful vote removed",
+	})
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ISBN registration groups for the languages readers add most often, used
+// to prefill the language of a book we don't know yet.
+var isbnGroupLanguages = []struct {
+	prefix   string
+	language string
+}{
+	{"978973", "Romanian"},
+	{"978606", "Romanian"},
+	{"97884", "Spanish"},
+	{"97888", "Italian"},
+	{"9780", "English"},
+	{"9781", "English"},
+	{"9782", "French"},
+	{"9783", "German"},
+	{"9784", "Japanese"},
+	{"9785", "Russian"},
+	{"9787", "Chinese"},
+}
+
+func isbnLanguage(isbn string) string {
+	for _, group := range isbnGroupLanguages {
+		if strings.HasPrefix(isbn, group.prefix) {
+			return group.language
+		}
+	}
+
+	return ""
+}
+
+func ScanISBN(c *fiber.Ctx) error {
+
+	if loggedUserID(c) == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	photo, message := uploadedPhoto(c, maxScanPhotoSize)
+
+	if photo == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	file, err := photo.Open()
+
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	defer file.Close()
+
+	isbn, err := utils.DecodeEAN13(file)
+
+	if errors.Is(err, utils.ErrNoBarcode) {
+		return c.Status(422).JSON(fiber.Map{
+			"data": "No barcode found, try a sharper photo of the back cover",
+		})
+	}
+
+	if errors.Is(err, utils.ErrImageTooLarge) {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Image is too large, send a photo of at most 4000x4000 pixels",
+		})
+	}
+
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Unsupported image, send a JPEG or PNG photo",
+		})
+	}
+
+	// ISBNs are stored as typed by whoever added the book, so compare
+	// without separators and accept the old ten digit form as well
+	candidates := []string{isbn}
+
+	if isbn10 := utils.ISBN10(isbn); isbn10 != "" {
+		candidates = append(candidates, isbn10)
+	}
+
+	var book models.Book
+
+	db.GetDB().Where("UPPER(REPLACE(REPLACE(isbn, '-', ''), ' ', '')) IN ?", candidates).First(&book)
+
+	if book.ID != 0 {
+		return c.JSON(fiber.Map{
+			"data":  book,
+			"isbn":  isbn,
+			"found": true,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": models.Book{
+			ISBN:     isbn,
+			Language: isbnLanguage(isbn),
+		},
+		"isbn":  isbn,
+		"found": false,
+	})
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetUserStats(c *fiber.Ctx) error {
+
+	userId, status, message := activityUserID(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	var user models.User
+
+	db.GetDB().Where("id = ?", userId).First(&user)
+
+	if user.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User not found",
+		})
+	}
+
+	year, err := strconv.Atoi(c.Query("year", strconv.Itoa(time.Now().Year())))
+
+	if err != nil || year < 1900 || year > 9999 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid year",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": services.Stats(user.ID, year),
+	})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// activityUserID returns the user whose reading activity is requested, which
+// only they and their friends can see.
+func activityUserID(c *fiber.Ctx) (int, int, string) {
+	userId, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil || userId == 0 {
+		return 0, 400, "Invalid request"
+	}
+
+	viewerId := loggedUserID(c)
+
+	if userId != viewerId && !services.AreFriends(userId, viewerId) {
+		return 0, 403, "Reading activity is only visible to friends"
+	}
+
+	return userId, 0, ""
+}
+
+func SetTimezone(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var request struct {
+		Timezone string `json:"timezone"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid request",
+		})
+	}
+
+	// an empty timezone goes back to UTC, "Local" would mean the server's
+	if _, err := time.LoadLocation(request.Timezone); err != nil || request.Timezone == "Local" || len(request.Timezone) > 50 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Unknown timezone, use an IANA name like Europe/Bucharest",
+		})
+	}
+
+	db.GetDB().Model(&models.User{}).Where("id = ?", userId).Update("timezone", request.Timezone)
+
+	// days are bucketed in the user's timezone, so stats and the longest
+	// streak behind ranks, badges and leaderboards can change
+	services.ReadingEvent(userId)
+
+	return c.JSON(fiber.Map{
+		"data": "Timezone updated successfully",
+	})
+}
+
+func GetUserStreak(c *fiber.Ctx) error {
+
+	userId, status, message := activityUserID(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": services.UserStreak(userId),
+	})
+}
+
+func GetUserHeatmap(c *fiber.Ctx) error {
+
+	userId, status, message := activityUserID(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	year, err := strconv.Atoi(c.Query("year", strconv.Itoa(time.Now().Year())))
+
+	if err != nil || year < 1900 || year > 9999 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid year",
+		})
+	}
+
+	days := services.Heatmap(userId, year)
+
+	var total, active, busiest int
+
+	for _, day := range days {
+		total += day.Pages
+
+		if day.Sessions > 0 {
+			active++
+		}
+
+		if day.Pages > busiest {
+			busiest = day.Pages
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":        days,
+		"year":        year,
+		"total_pages": total,
+		"active_days": active,
+		"max_pages":   busiest,
+	})
+}
+
+// GetUserActivity is the calendar of one month, ?month=2026-10 (the current
+// month by default), listing only the days with reading.
+func GetUserActivity(c *fiber.Ctx) error {
+
+	userId, status, message := activityUserID(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	loc := services.UserLocation(userId)
+
+	month, err := time.ParseInLocation("2006-01", c.Query("month", time.Now().In(loc).Format("2006-01")), loc)
+
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Month must look like 2026-10",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     services.DailyActivity(userId, loc, month, month.AddDate(0, 1, 0)),
+		"month":    month.Format("2006-01"),
+		"timezone": loc.String(),
+	})
+}
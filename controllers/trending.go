@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/catalinfl/readit-api/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetTrendingBooks(c *fiber.Ctx) error {
+
+	metric := c.Query("metric", "added")
+	window := c.Query("window", "week")
+
+	validMetric := false
+
+	for _, m := range services.TrendingMetrics {
+		if m == metric {
+			validMetric = true
+		}
+	}
+
+	if !validMetric {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Metric must be one of added, finished or progressed",
+		})
+	}
+
+	if _, ok := services.TrendingWindows[window]; !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Window must be one of day, week, month or all",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	books, refreshedAt := services.Trending(metric, window, c.Query("genre"), c.Query("language"), limit)
+
+	return c.JSON(fiber.Map{
+		"data":         books,
+		"metric":       metric,
+		"window":       window,
+		"refreshed_at": refreshedAt,
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/catalinfl/readit-api/db"
 	"github.com/catalinfl/readit-api/middlewares"
 	"github.com/catalinfl/readit-api/models"
+	"github.com/catalinfl/readit-api/services"
 	"github.com/catalinfl/readit-api/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -60,6 +61,8 @@ func CreateUser(c *fiber.Ctx) error {
 	}
 
 	user.Password = hashPassword(user.Password)
+	user.BranchID = nil
+	user.Rank = ""
 
 	var existingUser models.User
 
@@ -73,6 +76,11 @@ func CreateUser(c *fiber.Ctx) error {
 
 	db.GetDB().Create(&user)
 
+	// new readers start at the lowest rank, there is nothing to notify about
+	if err := services.EvaluateRank(user.ID, false); err != nil {
+		fmt.Println("Failed to evaluate rank of user", user.ID, err)
+	}
+
 	return c.JSON(fiber.Map{
 		"data": "User created successfully",
 	})
@@ -158,6 +166,28 @@ func checkPassword(hashedPassword, password string) bool {
 	return err == nil
 }
 
+func loggedUserID(c *fiber.Ctx) int {
+	token := c.Cookies("jwt_token")
+
+	if token == "" {
+		return 0
+	}
+
+	t := middlewares.VerifyTokenAndParse(token)
+
+	if t == nil {
+		return 0
+	}
+
+	id, ok := t["id"].(float64)
+
+	if !ok {
+		return 0
+	}
+
+	return int(id)
+}
+
 func GetUsers(c *fiber.Ctx) error {
 
 	var users []models.User
@@ -667,6 +697,21 @@ func GetUser(c *fiber.Ctx) error {
 	response["name"] = user.Name
 	response["email"] = user.Email
 	response["rank"] = user.Rank
+
+	var rank models.RankDefinition
+
+	if user.Rank != "" {
+		db.GetDB().Where("name = ?", user.Rank).First(&rank)
+	}
+
+	response["rank_icon"] = rank.Icon
+	response["badges"] = services.UserBadges(user.ID, showcaseSize)
+
+	var badgeCount int64
+
+	db.GetDB().Model(&models.UserBadge{}).Where("user_id = ?", user.ID).Count(&badgeCount)
+
+	response["badge_count"] = badgeCount
 	response["librarian"] = user.Librarian
 	response["admin"] = user.Admin
 	response["profile_pic"] = user.ProfilePic
@@ -719,6 +764,16 @@ func GetUser(c *fiber.Ctx) error {
 
 	db.GetDB().Where("user_id = ?", user.ID).Find(&userBooks)
 
+	var reviews []models.Review
+
+	db.GetDB().Where("user_id = ?", user.ID).Find(&reviews)
+
+	ratings := make(map[int]float64)
+
+	for _, review := range reviews {
+		ratings[review.BookID] = review.Rating
+	}
+
 	var responseBooks []map[string]interface{}
 
 	for _, booksFromDb := range userBooks {
@@ -731,6 +786,12 @@ func GetUser(c *fiber.Ctx) error {
 					bookFromUserResMap[k] = v
 				}
 
+				if rating, ok := ratings[booksFromUserRes.ID]; ok {
+					bookFromUserResMap["rating"] = rating
+				} else {
+					bookFromUserResMap["rating"] = nil
+				}
+
 				responseBooks = append(responseBooks, bookFromUserResMap)
 			}
 		}
@@ -850,3 +911,102 @@ func ModifyUser(c *fiber.Ctx) error {
 	})
 
 }
+
+func ExportUserData(c *fiber.Ctx) error {
+
+	userId := loggedUserID(c)
+
+	if userId == 0 {
+		return c.Status(401).JSON(fiber.Map{
+			"data": "Unauthorized, please log in",
+		})
+	}
+
+	var user models.User
+
+	db.GetDB().Where("id = ?", userId).First(&user)
+
+	if user.ID == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"data": "User not found",
+		})
+	}
+
+	var userBooks []models.UserBooks
+
+	db.GetDB().Where("user_id = ?", user.ID).Find(&userBooks)
+
+	bookIds := make([]uint, 0, len(userBooks))
+
+	for _, userBook := range userBooks {
+		bookIds = append(bookIds, userBook.BookID)
+	}
+
+	var books []models.Book
+
+	if len(bookIds) > 0 {
+		db.GetDB().Where("id IN ?", bookIds).Find(&books)
+	}
+
+	var friends []models.Friends
+
+	db.GetDB().Where("sender_id = ? OR receiver_id = ?", user.ID, user.ID).Find(&friends)
+
+	var reviews []models.Review
+
+	db.GetDB().Where("user_id = ?", user.ID).Find(&reviews)
+
+	var quotes []models.Quote
+
+	db.GetDB().Where("user_id = ?", user.ID).Find(&quotes)
+
+	var journal []models.JournalEntry
+
+	db.GetDB().Where("user_id = ?", user.ID).Order("entry_date").Find(&journal)
+
+	var sessions []models.ReadingSession
+
+	db.GetDB().Where("user_id = ?", user.ID).Order("read_at").Find(&sessions)
+
+	var readThroughs []models.ReadThrough
+
+	db.GetDB().Where("user_id = ?", user.ID).Order("user_books_id, number").Find(&readThroughs)
+
+	var goals []models.ReadingGoal
+
+	db.GetDB().Where("user_id = ?", user.ID).Order("year, month").Find(&goals)
+
+	var rankHistory []models.RankHistory
+
+	db.GetDB().Where("user_id = ?", user.ID).Order("created_at").Find(&rankHistory)
+
+	badges := services.UserBadges(user.ID, 0)
+
+	c.Set(fiber.HeaderContentDisposition, "attachment; filename=\"readit-export.json\"")
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"exported_at": time.Now(),
+			"profile": fiber.Map{
+				"id":                  user.ID,
+				"name":                user.Name,
+				"real_name":           user.RealName,
+				"email":               user.Email,
+				"rank":                user.Rank,
+				"profile_pic":         user.ProfilePic,
+				"leaderboard_opt_out": user.LeaderboardOptOut,
+			},
+			"user_books":    userBooks,
+			"books":         books,
+			"friends":       friends,
+			"reviews":       reviews,
+			"quotes":        quotes,
+			"journal":       journal,
+			"sessions":      sessions,
+			"read_throughs": readThroughs,
+			"goals":         goals,
+			"rank_history":  rankHistory,
+			"badges":        badges,
+		},
+	})
+}
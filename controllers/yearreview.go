@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/catalinfl/readit-api/services"
+	"github.com/catalinfl/readit-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var monthInitials = []string{"J", "F", "M", "A", "M", "J", "J", "A", "S", "O", "N", "D"}
+
+func shareCard(review services.YearReview) utils.ShareCard {
+	card := utils.ShareCard{
+		Title:    fmt.Sprintf("%d IN BOOKS", review.Year),
+		Subtitle: review.UserName,
+		Stats: []utils.CardStat{
+			{Label: "books finished", Value: strconv.Itoa(review.TotalBooks)},
+			{Label: "pages read", Value: strconv.Itoa(review.TotalPages)},
+		},
+		ChartLabels: monthInitials,
+		Footer:      "READIT",
+	}
+
+	if review.TopGenre != "" {
+		card.Stats = append(card.Stats, utils.CardStat{Label: "favourite genre", Value: review.TopGenre})
+	}
+
+	if review.LongestBook != nil {
+		card.Stats = append(card.Stats, utils.CardStat{Label: "longest book, pages", Value: strconv.Itoa(review.LongestBook.Pages)})
+		card.Highlights = append(card.Highlights, "Longest: "+review.LongestBook.Title)
+	}
+
+	if review.FirstBook != nil {
+		card.Highlights = append(card.Highlights, "First: "+review.FirstBook.Title)
+	}
+
+	if review.LastBook != nil {
+		card.Highlights = append(card.Highlights, "Last: "+review.LastBook.Title)
+	}
+
+	for _, month := range review.Months {
+		card.Chart = append(card.Chart, month.Books)
+	}
+
+	return card
+}
+
+// GetYearInReview returns the recap of a year as JSON, or as a PNG share card
+// with ?format=png.
+func GetYearInReview(c *fiber.Ctx) error {
+
+	userId, status, message := activityUserID(c)
+
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"data": message,
+		})
+	}
+
+	year, err := strconv.Atoi(c.Query("year", strconv.Itoa(time.Now().Year())))
+
+	if err != nil || year < 1900 || year > 9999 {
+		return c.Status(400).JSON(fiber.Map{
+			"data": "Invalid year",
+		})
+	}
+
+	review := services.YearInReview(userId, year)
+
+	if c.Query("format") != "png" {
+		return c.JSON(fiber.Map{
+			"data": review,
+		})
+	}
+
+	var buf bytes.Buffer
+
+	if err := shareCard(review).WritePNG(&buf); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"data": "Failed to render share card",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"year-in-review-%d.png\"", year))
+
+	return c.Send(buf.Bytes())
+}
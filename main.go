@@ -3,7 +3,9 @@ package main
 import (
 	"github.com/catalinfl/readit-api/db"
 	"github.com/catalinfl/readit-api/middlewares"
+	"github.com/catalinfl/readit-api/models"
 	"github.com/catalinfl/readit-api/routes"
+	"github.com/catalinfl/readit-api/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -13,6 +15,13 @@ func main() {
 
 	db.Connect()
 
+	if models.RanksSeeded {
+		// users were ranked by the old hardcoded thresholds, fix that quietly
+		go services.EvaluateAllRanks(false)
+	}
+
+	services.StartJobs()
+
 	app.Use(middlewares.UseCORS())
 
 	routes.Setup(app)
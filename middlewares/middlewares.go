@@ -120,65 +120,6 @@ func VerifyIfAdmin(c *fiber.Ctx) error {
 	return c.Next()
 }
 
-func CountUserBooks(c *fiber.Ctx) error {
-	token := c.Cookies("jwt_token")
-
-	if token == "" {
-		return c.Status(401).JSON(fiber.Map{
-			"message": "Unauthorized",
-		})
-	}
-
-	str := VerifyTokenAndParse(token)
-
-	if str == nil {
-		return c.Status(401).JSON(fiber.Map{
-			"message": "Unauthorized, please log in",
-		})
-	}
-
-	var user models.User
-
-	db.GetDB().Where("name = ?", str).First(&user)
-
-	if user.ID == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"message": "User not found",
-		})
-	}
-
-	var userBooks models.UserBooks
-
-	var userBooksLength int64 = 0
-
-	db.GetDB().Where("user_id = ?", user.ID).Find(&userBooks).Count(&userBooksLength)
-
-	if userBooksLength < 10 && user.Rank != "Bronze" {
-		user.Rank = "Bronze"
-		db.GetDB().Model(&user).Updates(user)
-		c.Next()
-
-		return nil
-	}
-
-	if (userBooksLength > 10 && userBooksLength < 20) && user.Rank != "" {
-		user.Rank = "Silver"
-		db.GetDB().Model(&user).Updates(user)
-		c.Next()
-		return nil
-	}
-
-	if userBooksLength > 20 && user.Rank != "Gold" {
-		user.Rank = "Gold"
-		db.GetDB().Model(&user).Updates(user)
-		c.Next()
-		return nil
-	}
-
-	return c.Next()
-
-}
-
 func VerifyTokenAndParse(token string) map[string]interface{} {
 
 	godotenv.Load()
@@ -197,8 +138,9 @@ func VerifyTokenAndParse(token string) map[string]interface{} {
 		fmt.Println(err)
 	}
 
-	if !tok.Valid {
+	if tok == nil || !tok.Valid {
 		fmt.Println("Token is not valid")
+		return nil
 	}
 
 	if claims, ok := tok.Claims.(jwt.MapClaims); ok && tok.Valid {
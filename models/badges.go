@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+const (
+	BadgeBooksFinished = "books_finished"
+	BadgeGenreBooks    = "genre_books"
+	BadgeLongBook      = "long_book"
+	BadgePagesRead     = "pages_read"
+	BadgeStreak        = "streak"
+)
+
+// BadgeDefinition is an achievement awarded once its Kind reaches Threshold,
+// Genre narrows genre_books badges to one genre (any genre when empty).
+type BadgeDefinition struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:60;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	Icon        string    `gorm:"size:255" json:"icon"`
+	Kind        string    `gorm:"size:30" json:"kind"`
+	Threshold   int       `json:"threshold"`
+	Genre       string    `gorm:"size:100" json:"genre"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UserBadge struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	UserID    int       `gorm:"uniqueIndex:idx_user_badge" json:"user_id"`
+	BadgeID   int       `gorm:"uniqueIndex:idx_user_badge" json:"badge_id"`
+	AwardedAt time.Time `json:"awarded_at"`
+}
+
+// DefaultBadges are created along with the table, like the default ranks.
+var DefaultBadges = []BadgeDefinition{
+	{Name: "First Chapter", Description: "Finished a book", Kind: BadgeBooksFinished, Threshold: 1},
+	{Name: "Genre Fan", Description: "Finished 5 books in one genre", Kind: BadgeGenreBooks, Threshold: 5},
+	{Name: "Doorstopper", Description: "Read a 1000-page book", Kind: BadgeLongBook, Threshold: 1000},
+	{Name: "On a Roll", Description: "Read 7 days in a row", Kind: BadgeStreak, Threshold: 7},
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	TransferRequested = "requested"
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+type Branch struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;uniqueIndex" json:"name"`
+	Address   string    `gorm:"size:255" json:"address"`
+	Phone     string    `gorm:"size:30" json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TransferRequest struct {
+	ID           int        `gorm:"primaryKey" json:"id"`
+	CopyID       int        `gorm:"index" json:"copy_id"`
+	FromBranchID int        `gorm:"index" json:"from_branch_id"`
+	ToBranchID   int        `gorm:"index" json:"to_branch_id"`
+	RequestedBy  int        `json:"requested_by"`
+	Status       string     `gorm:"size:20;index" json:"status"`
+	Note         string     `gorm:"size:255" json:"note"`
+	ShippedAt    *time.Time `json:"shipped_at"`
+	ReceivedAt   *time.Time `json:"received_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+const (
+	CopyAvailable   = "available"
+	CopyCheckedOut  = "checked_out"
+	CopyOnHold      = "on_hold"
+	CopyLost        = "lost"
+	CopyMaintenance = "maintenance"
+	CopyInTransit   = "in_transit"
+)
+
+const (
+	ActionCheckout = "checkout"
+	ActionCheckin  = "checkin"
+	ActionRenew    = "renew"
+	ActionStatus   = "status"
+	ActionHold     = "hold"
+	ActionTransfer = "transfer"
+)
+
+type Copy struct {
+	ID            int       `gorm:"primaryKey" json:"id"`
+	BookID        int       `gorm:"index" json:"book_id"`
+	BranchID      *int      `gorm:"index" json:"branch_id"`
+	Barcode       string    `gorm:"size:50;uniqueIndex" json:"barcode"`
+	Condition     string    `gorm:"size:20" json:"condition"`
+	ShelfLocation string    `gorm:"size:50" json:"shelf_location"`
+	Status        string    `gorm:"size:20;default:available" json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type Loan struct {
+	ID           int        `gorm:"primaryKey" json:"id"`
+	CopyID       int        `gorm:"index" json:"copy_id"`
+	BookID       int        `gorm:"index" json:"book_id"`
+	BranchID     *int       `gorm:"index" json:"branch_id"`
+	UserID       int        `gorm:"index" json:"user_id"`
+	LibrarianID  int        `json:"librarian_id"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	DueAt        time.Time  `json:"due_at"`
+	ReturnedAt   *time.Time `json:"returned_at"`
+	Renewals     int        `json:"renewals"`
+}
+
+type CirculationTransaction struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	CopyID      int       `gorm:"index" json:"copy_id"`
+	LoanID      *int      `json:"loan_id"`
+	UserID      int       `gorm:"index" json:"user_id"`
+	LibrarianID int       `json:"librarian_id"`
+	Action      string    `gorm:"size:20" json:"action"`
+	Note        string    `gorm:"size:255" json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
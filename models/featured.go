@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type FeaturedCollection struct {
+	ID          int        `gorm:"primaryKey" json:"id"`
+	Title       string     `gorm:"size:100" json:"title"`
+	Description string     `gorm:"size:1000" json:"description"`
+	Banner      string     `json:"banner"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Position    int        `json:"position"`
+	CreatedBy   int        `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type FeaturedItem struct {
+	ID           int    `gorm:"primaryKey" json:"id"`
+	CollectionID int    `gorm:"index" json:"collection_id"`
+	BookID       int    `json:"book_id"`
+	Position     int    `json:"position"`
+	Blurb        string `gorm:"size:500" json:"blurb"`
+}
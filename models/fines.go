@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	EntryFine    = "fine"
+	EntryLost    = "lost"
+	EntryPayment = "payment"
+	EntryWaiver  = "waiver"
+)
+
+type FinePolicy struct {
+	ID                  int       `gorm:"primaryKey" json:"id"`
+	PerDayCents         int       `json:"per_day_cents"`
+	GraceDays           int       `json:"grace_days"`
+	MaxFineCents        int       `json:"max_fine_cents"`
+	BlockThresholdCents int       `json:"block_threshold_cents"`
+	LostFeeCents        int       `gorm:"default:2500" json:"lost_fee_cents"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// AccountEntry is one line of a patron ledger. Fines are positive, payments
+// and waivers are negative, so the balance is the plain sum of the amounts.
+type AccountEntry struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	UserID      int       `gorm:"index" json:"user_id"`
+	LoanID      *int      `gorm:"index" json:"loan_id"`
+	Kind        string    `gorm:"size:20" json:"kind"`
+	AmountCents int       `json:"amount_cents"`
+	Note        string    `gorm:"size:255" json:"note"`
+	LibrarianID int       `json:"librarian_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	GoalBooks = "books"
+	GoalPages = "pages"
+)
+
+// ReadingGoal is a target for a year, or for one month of it when Month is
+// set (1-12). Month 0 means the goal covers the whole year.
+type ReadingGoal struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	UserID    int       `gorm:"uniqueIndex:idx_goals_user_period" json:"user_id"`
+	Year      int       `gorm:"uniqueIndex:idx_goals_user_period" json:"year"`
+	Month     int       `gorm:"uniqueIndex:idx_goals_user_period" json:"month"`
+	Kind      string    `gorm:"size:10;uniqueIndex:idx_goals_user_period" json:"kind"`
+	Target    int       `json:"target"`
+	Shared    bool      `json:"shared"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
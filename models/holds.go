@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldFulfilled = "fulfilled"
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+)
+
+type Hold struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	BookID    int        `gorm:"index" json:"book_id"`
+	UserID    int        `gorm:"index" json:"user_id"`
+	CopyID    *int       `json:"copy_id"`
+	Status    string     `gorm:"size:20;index" json:"status"`
+	ReadyAt   *time.Time `json:"ready_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type Notification struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	UserID    int       `gorm:"index" json:"user_id"`
+	Kind      string    `gorm:"size:30" json:"kind"`
+	Message   string    `gorm:"size:255" json:"message"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
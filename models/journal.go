@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type JournalEntry struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	UserBooksID int       `gorm:"index" json:"user_books_id"`
+	UserID      int       `gorm:"index" json:"user_id"`
+	BookID      int       `json:"book_id"`
+	EntryDate   time.Time `gorm:"type:date" json:"entry_date"`
+	Mood        string    `gorm:"size:20" json:"mood"`
+	Page        *uint     `json:"page"`
+	Body        string    `gorm:"size:10000" json:"body"`
+	Shared      bool      `json:"shared"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
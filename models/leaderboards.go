@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodYear  = "year"
+)
+
+// LeaderboardEntry holds what a user read in one leaderboard window, it is
+// kept up to date on reading events so boards are a plain sorted read.
+type LeaderboardEntry struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	UserID      int       `gorm:"uniqueIndex:idx_leaderboard_user_period" json:"user_id"`
+	Period      string    `gorm:"size:10;uniqueIndex:idx_leaderboard_user_period;index:idx_leaderboard_period" json:"period"`
+	PeriodStart time.Time `gorm:"uniqueIndex:idx_leaderboard_user_period;index:idx_leaderboard_period" json:"period_start"`
+	Pages       int       `json:"pages"`
+	Books       int       `json:"books"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type BookList struct {
+	ID             int       `gorm:"primaryKey" json:"id"`
+	UserID         int       `gorm:"index" json:"user_id"`
+	Name           string    `gorm:"size:100" json:"name"`
+	Description    string    `gorm:"size:1000" json:"description"`
+	Visibility     string    `gorm:"size:10;default:public" json:"visibility"`
+	LikesCount     int       `gorm:"default:0" json:"likes_count"`
+	FollowersCount int       `gorm:"default:0" json:"followers_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type BookListItem struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	ListID    int       `gorm:"index" json:"list_id"`
+	BookID    int       `gorm:"index" json:"book_id"`
+	Position  int       `json:"position"`
+	Comment   string    `gorm:"size:1000" json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BookListLike struct {
+	ListID    int       `gorm:"primaryKey;autoIncrement:false" json:"list_id"`
+	UserID    int       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BookListFollow struct {
+	ListID    int       `gorm:"primaryKey;autoIncrement:false" json:"list_id"`
+	UserID    int       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
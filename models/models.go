@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -13,18 +14,19 @@ import (
 type MultiString []string
 
 type Book struct {
-	ID          int         `gorm:"primaryKey" json:"id"`
-	Title       string      `gorm:"size:100" json:"title"`
-	Author      string      `gorm:"size:100" json:"author"`
-	Year        string      `gorm:"size:100" json:"year"`
-	ISBN        string      `gorm:"size:100" json:"isbn"`
-	Language    string      `gorm:"size:100" json:"language"`
-	Pages       uint        `json:"pages"`
-	Genre       string      `gorm:"size:100" json:"genre"`
-	Publisher   string      `gorm:"size:100" json:"publisher"`
-	Description string      `gorm:"size:1000" json:"description"`
-	Photos      MultiString `gorm:"type:text" json:"photos"`
-	Users       []User      `gorm:"many2many:user_books"`
+	ID           int         `gorm:"primaryKey" json:"id"`
+	Title        string      `gorm:"size:100" json:"title"`
+	Author       string      `gorm:"size:100" json:"author"`
+	Year         string      `gorm:"size:100" json:"year"`
+	ISBN         string      `gorm:"size:100" json:"isbn"`
+	Language     string      `gorm:"size:100" json:"language"`
+	Pages        uint        `json:"pages"`
+	Genre        string      `gorm:"size:100" json:"genre"`
+	Publisher    string      `gorm:"size:100" json:"publisher"`
+	Description  string      `gorm:"size:1000" json:"description"`
+	Photos       MultiString `gorm:"type:text" json:"photos"`
+	AudioMinutes uint        `json:"audio_minutes"`
+	Users        []User      `gorm:"many2many:user_books"`
 }
 
 type Friends struct {
@@ -64,32 +66,154 @@ func (MultiString) GormDBDataType(db *gorm.DB, field *schema.Field) string {
 }
 
 type User struct {
-	ID         int    `gorm:"primaryKey" json:"id"`
-	Name       string `gorm:"size:40" json:"name"`
-	RealName   string `gorm:"size:60" json:"real_name"`
-	Email      string `gorm:"size:40" json:"email"`
-	Password   string `gorm:"size:100" json:"password"`
-	Rank       string `gorm:"size:20" json:"rank"`
-	Books      []Book `gorm:"many2many:user_books"`
-	Librarian  bool   `json:"librarian"`
-	Admin      bool   `json:"admin"`
-	ProfilePic string `json:"profile_pic"`
+	ID                int    `gorm:"primaryKey" json:"id"`
+	Name              string `gorm:"size:40" json:"name"`
+	RealName          string `gorm:"size:60" json:"real_name"`
+	Email             string `gorm:"size:40" json:"email"`
+	Password          string `gorm:"size:100" json:"password"`
+	Rank              string `gorm:"size:20" json:"rank"`
+	Books             []Book `gorm:"many2many:user_books"`
+	Librarian         bool   `json:"librarian"`
+	Admin             bool   `json:"admin"`
+	ProfilePic        string `json:"profile_pic"`
+	BranchID          *int   `json:"branch_id"`
+	Timezone          string `gorm:"size:50" json:"timezone"`
+	LeaderboardOptOut bool   `json:"leaderboard_opt_out"`
 }
 
+const (
+	StateWantToRead = "want_to_read"
+	StateReading    = "reading"
+	StatePaused     = "paused"
+	StateFinished   = "finished"
+	StateAbandoned  = "abandoned"
+)
+
+const (
+	FormatPaper     = "paper"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
 type UserBooks struct {
-	UserBooksID int    `gorm:"primaryKey" json:"user_books_id" db:"user_books.id"`
-	UserID      uint   `json:"user_id" db:"user.id"`
-	BookID      uint   `json:"book_id" db:"book.id"`
-	PagesRead   uint   `json:"pages_read" db:"pages_read"`
-	BookState   string `json:"book_state" db:"book_state"`
+	UserBooksID          int        `gorm:"primaryKey" json:"user_books_id" db:"user_books.id"`
+	UserID               uint       `json:"user_id" db:"user.id"`
+	BookID               uint       `json:"book_id" db:"book.id"`
+	PagesRead            uint       `json:"pages_read" db:"pages_read"`
+	Format               string     `gorm:"size:20;default:paper" json:"format" db:"format"`
+	Progress             float64    `json:"progress" db:"progress"`
+	Percent              float64    `json:"percent" db:"percent"`
+	BookState            string     `gorm:"size:20;default:want_to_read" json:"book_state" db:"book_state"`
+	StartedAt            *time.Time `json:"started_at" db:"started_at"`
+	FinishedAt           *time.Time `json:"finished_at" db:"finished_at"`
+	CurrentReadThroughID *int       `json:"current_read_through_id" db:"current_read_through_id"`
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 func MigrateBooks(db *gorm.DB) {
-	err := db.AutoMigrate(&Book{}, &User{}, &UserBooks{}, &Friends{})
+	seedRanks := !db.Migrator().HasTable(&RankDefinition{})
+	seedBadges := !db.Migrator().HasTable(&BadgeDefinition{})
+
+	err := db.AutoMigrate(
+		&Book{}, &User{}, &UserBooks{}, &Friends{},
+		&Review{}, &ReviewComment{}, &ReviewVote{},
+		&Quote{}, &JournalEntry{},
+		&BookList{}, &BookListItem{}, &BookListLike{}, &BookListFollow{},
+		&FeaturedCollection{}, &FeaturedItem{},
+		&Copy{}, &Loan{}, &CirculationTransaction{},
+		&Hold{}, &Notification{},
+		&FinePolicy{}, &AccountEntry{},
+		&Branch{}, &TransferRequest{},
+		&ReadingSession{}, &ReadThrough{},
+		&ReadingGoal{},
+		&RankDefinition{}, &RankHistory{},
+		&BadgeDefinition{}, &UserBadge{},
+		&LeaderboardEntry{},
+	)
 
 	if err != nil {
 		panic(err)
 	}
 
+	if seedRanks {
+		ranks := append([]RankDefinition{}, DefaultRanks...)
+
+		if err := db.Create(&ranks).Error; err != nil {
+			panic(err)
+		}
+
+		RanksSeeded = true
+	}
+
+	if seedBadges {
+		badges := append([]BadgeDefinition{}, DefaultBadges...)
+
+		if err := db.Create(&badges).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	// user books saved before reading states were enforced have no state
+	err = db.Model(&UserBooks{}).Where("book_state = '' OR book_state IS NULL").Updates(map[string]interface{}{
+		"book_state": gorm.Expr("CASE WHEN finished_at IS NOT NULL THEN ? WHEN pages_read > 0 THEN ? ELSE ? END", StateFinished, StateReading, StateWantToRead),
+		"started_at": gorm.Expr("CASE WHEN pages_read > 0 THEN created_at END"),
+	}).Error
+
+	if err != nil {
+		panic(err)
+	}
+
+	migrateReadThroughs(db)
+	migrateFormats(db)
+
 	fmt.Println("Books migration has been processed")
 }
+
+// migrateReadThroughs gives every user book saved before re-reads existed its
+// first read-through and attaches the sessions logged so far to it.
+func migrateReadThroughs(db *gorm.DB) {
+	statements := []string{
+		`INSERT INTO read_throughs (user_books_id, user_id, book_id, number, state, pages_read, started_at, finished_at, created_at, updated_at)
+		SELECT user_books_id, user_id, book_id, 1, book_state, pages_read, started_at, finished_at, created_at, updated_at
+		FROM user_books
+		WHERE current_read_through_id IS NULL`,
+		`UPDATE user_books SET current_read_through_id = read_throughs.id
+		FROM read_throughs
+		WHERE read_throughs.user_books_id = user_books.user_books_id AND user_books.current_read_through_id IS NULL`,
+		`UPDATE reading_sessions SET read_through_id = user_books.current_read_through_id
+		FROM user_books
+		WHERE user_books.user_books_id = reading_sessions.user_books_id AND reading_sessions.read_through_id IS NULL`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+// migrateFormats fills in the native progress and percentage of paper books
+// tracked before formats existed, where progress was only pages read.
+func migrateFormats(db *gorm.DB) {
+	statements := []string{
+		`UPDATE user_books SET progress = pages_read
+		WHERE format = 'paper' AND progress = 0 AND pages_read > 0`,
+		`UPDATE user_books SET percent = LEAST(100, round(user_books.pages_read * 100.0 / books.pages, 1))
+		FROM books
+		WHERE books.id = user_books.book_id AND books.pages > 0 AND user_books.format = 'paper' AND user_books.percent = 0 AND user_books.pages_read > 0`,
+		`UPDATE read_throughs SET progress = pages_read
+		WHERE format = 'paper' AND progress = 0 AND pages_read > 0`,
+		`UPDATE read_throughs SET percent = LEAST(100, round(read_throughs.pages_read * 100.0 / books.pages, 1))
+		FROM books
+		WHERE books.id = read_throughs.book_id AND books.pages > 0 AND read_throughs.format = 'paper' AND read_throughs.percent = 0 AND read_throughs.pages_read > 0`,
+		`UPDATE reading_sessions SET progress = end_page
+		WHERE format = 'paper' AND progress = 0 AND end_page > 0`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type Quote struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	UserBooksID int       `gorm:"index" json:"user_books_id"`
+	UserID      int       `gorm:"index" json:"user_id"`
+	BookID      int       `gorm:"index" json:"book_id"`
+	Text        string    `gorm:"size:2000" json:"text"`
+	Page        uint      `json:"page"`
+	Note        string    `gorm:"size:1000" json:"note"`
+	Public      bool      `json:"public"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
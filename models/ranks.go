@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// RankDefinition is a rank users reach once they meet every non-zero
+// minimum; when several ranks match, the one with the highest Level wins.
+type RankDefinition struct {
+	ID               int       `gorm:"primaryKey" json:"id"`
+	Name             string    `gorm:"size:20;uniqueIndex" json:"name"`
+	Icon             string    `gorm:"size:255" json:"icon"`
+	Level            int       `json:"level"`
+	MinBooksFinished int       `json:"min_books_finished"`
+	MinPagesRead     int       `json:"min_pages_read"`
+	MinStreakDays    int       `json:"min_streak_days"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type RankHistory struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	UserID       int       `gorm:"index" json:"user_id"`
+	RankID       *int      `json:"rank_id"`
+	Rank         string    `gorm:"size:20" json:"rank"`
+	PreviousRank string    `gorm:"size:20" json:"previous_rank"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RanksSeeded is set when MigrateBooks created the default ranks, every user
+// then still has a rank from the old hardcoded thresholds.
+var RanksSeeded bool
+
+// DefaultRanks replace the thresholds that used to be hardcoded, they are only
+// created along with the table so admins can delete them.
+var DefaultRanks = []RankDefinition{
+	{Name: "Bronze", Level: 1},
+	{Name: "Silver", Level: 2, MinBooksFinished: 10},
+	{Name: "Gold", Level: 3, MinBooksFinished: 20},
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ReadThrough is one reading of a user book from start to end; re-reading a
+// book starts a new one. The user book mirrors its current read-through.
+type ReadThrough struct {
+	ID          int        `gorm:"primaryKey" json:"id"`
+	UserBooksID int        `gorm:"index" json:"user_books_id"`
+	UserID      int        `gorm:"index" json:"user_id"`
+	BookID      int        `gorm:"index" json:"book_id"`
+	Number      int        `json:"number"`
+	State       string     `gorm:"size:20" json:"state"`
+	PagesRead   uint       `json:"pages_read"`
+	Format      string     `gorm:"size:20;default:paper" json:"format"`
+	Progress    float64    `json:"progress"`
+	Percent     float64    `json:"percent"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `gorm:"index" json:"finished_at"`
+	Rating      *float64   `json:"rating"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type Review struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	UserID       int       `gorm:"uniqueIndex:idx_reviews_user_book" json:"user_id"`
+	BookID       int       `gorm:"uniqueIndex:idx_reviews_user_book;index" json:"book_id"`
+	Rating       float64   `json:"rating"`
+	Text         string    `gorm:"size:5000" json:"text"`
+	HelpfulCount int       `gorm:"default:0" json:"helpful_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ReviewComment struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	ReviewID  int       `gorm:"index" json:"review_id"`
+	UserID    int       `json:"user_id"`
+	ParentID  *int      `json:"parent_id"`
+	Text      string    `gorm:"size:2000" json:"text"`
+	Deleted   bool      `json:"deleted"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReviewVote struct {
+	ReviewID  int       `gorm:"primaryKey;autoIncrement:false" json:"review_id"`
+	UserID    int       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ReadingSession is one progress update on a user book. Pages is the number
+// of pages moved forward in the session, going back never counts as reading.
+// Ebooks and audiobooks log page equivalents, with Progress in their own unit.
+type ReadingSession struct {
+	ID              int       `gorm:"primaryKey" json:"id"`
+	UserBooksID     int       `gorm:"index" json:"user_books_id"`
+	ReadThroughID   *int      `gorm:"index" json:"read_through_id"`
+	UserID          int       `gorm:"index" json:"user_id"`
+	BookID          int       `gorm:"index" json:"book_id"`
+	StartPage       uint      `json:"start_page"`
+	EndPage         uint      `json:"end_page"`
+	Pages           uint      `json:"pages"`
+	Format          string    `gorm:"size:20;default:paper" json:"format"`
+	Progress        float64   `json:"progress"`
+	DurationMinutes *uint     `json:"duration_minutes"`
+	ReadAt          time.Time `gorm:"index" json:"read_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
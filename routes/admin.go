@@ -14,10 +14,27 @@ func adminRoute(api fiber.Router) {
 
 	adminRoute.Get("/friends-requests", controllers.GetAllFriendsRequests)
 	adminRoute.Get("/users", controllers.GetUsers)
+	adminRoute.Get("/fine-policy", controllers.GetFinePolicy)
+	adminRoute.Get("/branches", controllers.GetBranches)
+	adminRoute.Get("/ranks", controllers.GetRanks)
+	adminRoute.Get("/badges", controllers.GetBadges)
+
+	adminRoute.Post("/branches", controllers.CreateBranch)
+	adminRoute.Post("/ranks", controllers.CreateRank)
+	adminRoute.Post("/badges", controllers.CreateBadge)
+	adminRoute.Post("/badges/backfill", controllers.BackfillBadges)
 
 	adminRoute.Put("/promote/:id", controllers.PromoteToLibrarian)
 	adminRoute.Put("/users/:id", controllers.ModifyUser)
+	adminRoute.Put("/fine-policy", controllers.ModifyFinePolicy)
+	adminRoute.Put("/branches/:id", controllers.ModifyBranch)
+	adminRoute.Put("/users/:id/branch", controllers.AssignLibrarianBranch)
+	adminRoute.Put("/ranks/:id", controllers.ModifyRank)
+	adminRoute.Put("/badges/:id", controllers.ModifyBadge)
 
 	adminRoute.Delete("/users/:id", controllers.DeleteUser)
 	adminRoute.Delete("/book/:id", controllers.DeleteBook)
+	adminRoute.Delete("/branches/:id", controllers.DeleteBranch)
+	adminRoute.Delete("/ranks/:id", controllers.DeleteRank)
+	adminRoute.Delete("/badges/:id", controllers.DeleteBadge)
 }
@@ -11,17 +11,27 @@ func booksRoute(api fiber.Router) {
 
 	bookRoute.Get("/", controllers.GetAllBooks)
 	bookRoute.Get("/book-photo/:id", controllers.GetBooksPhoto)
+	bookRoute.Get("/similar/:id", controllers.GetSimilarBooks)
+	bookRoute.Get("/trending", controllers.GetTrendingBooks)
+	bookRoute.Get("/availability/:id", controllers.GetBookAvailability)
+	bookRoute.Post("/scan-isbn", middlewares.VerifyLogin, controllers.ScanISBN)
 	bookRoute.Get("/:id", controllers.GetBook)
 
 	bookRoute.Get("/user-books", controllers.GetAllUserBooks)
 	bookRoute.Get("/user-books/:id", controllers.GetUserBooks)
+	bookRoute.Get("/user-books/sessions/:userBooksId", middlewares.VerifyLogin, controllers.GetReadingSessions)
+	bookRoute.Get("/user-books/read-throughs/:userBooksId", middlewares.VerifyLogin, controllers.GetReadThroughs)
 
-	bookRoute.Post("/user-books", middlewares.CountUserBooks, controllers.CreateUserBook)
-	bookRoute.Delete("/user-books/:bookId", controllers.DeleteUserBook)
+	bookRoute.Post("/user-books", middlewares.VerifyLogin, controllers.CreateUserBook)
+	bookRoute.Post("/user-books/reread", middlewares.VerifyLogin, controllers.RereadUserBook)
+	bookRoute.Put("/user-books/read-throughs/:id/rating", middlewares.VerifyLogin, controllers.RateReadThrough)
+	bookRoute.Delete("/user-books/:bookId", middlewares.VerifyLogin, controllers.DeleteUserBook)
 
 	bookRoute.Get("/get-paginated", controllers.GetBooksPaginated)
 	bookRoute.Get("/get-infinite/:id", controllers.GetBooks)
 
 	bookRoute.Put("/edit-pages", controllers.UpdateReadingBook)
+	bookRoute.Put("/edit-state", middlewares.VerifyLogin, controllers.UpdateReadingState)
+	bookRoute.Put("/edit-format", middlewares.VerifyLogin, controllers.UpdateUserBookFormat)
 	bookRoute.Put("/edit-genre", controllers.UpdateGenre)
 }
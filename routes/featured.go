@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/catalinfl/readit-api/controllers"
+	"github.com/gofiber/fiber/v2"
+)
+
+func featuredRoute(api fiber.Router) {
+	featuredRoute := api.Group("/featured")
+
+	featuredRoute.Get("/", controllers.GetFeatured)
+	featuredRoute.Get("/banner/:id", controllers.GetFeaturedBanner)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/catalinfl/readit-api/controllers"
+	"github.com/catalinfl/readit-api/middlewares"
+	"github.com/gofiber/fiber/v2"
+)
+
+func holdsRoute(api fiber.Router) {
+	holdRoute := api.Group("/holds")
+
+	holdRoute.Get("/mine", middlewares.VerifyLogin, controllers.GetMyHolds)
+	holdRoute.Get("/book/:bookId/position", middlewares.VerifyLogin, controllers.GetHoldPosition)
+
+	holdRoute.Post("/book/:bookId", middlewares.VerifyLogin, controllers.PlaceHold)
+	holdRoute.Delete("/:id", middlewares.VerifyLogin, controllers.CancelHold)
+}
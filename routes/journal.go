@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/catalinfl/readit-api/controllers"
+	"github.com/catalinfl/readit-api/middlewares"
+	"github.com/gofiber/fiber/v2"
+)
+
+func journalRoute(api fiber.Router) {
+	journalRoute := api.Group("/journal")
+
+	journalRoute.Get("/user-book/:userBooksId", controllers.GetJournalEntries)
+
+	journalRoute.Post("/", middlewares.VerifyLogin, controllers.CreateJournalEntry)
+	journalRoute.Put("/:id", middlewares.VerifyLogin, controllers.ModifyJournalEntry)
+	journalRoute.Delete("/:id", middlewares.VerifyLogin, controllers.DeleteJournalEntry)
+}
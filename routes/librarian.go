@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/catalinfl/readit-api/controllers"
+	"github.com/catalinfl/readit-api/middlewares"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -15,4 +16,43 @@ func librarianRoute(api fiber.Router) {
 	librarianRoute.Delete("/delete-photo/:bookId", controllers.DeleteBookPhoto)
 	librarianRoute.Delete("/delete-book/:bookId", controllers.DeleteBookLibrarian)
 
+	librarianRoute.Get("/featured", middlewares.VerifyIfLibrarian, controllers.GetAllFeaturedLibrarian)
+	librarianRoute.Post("/featured", middlewares.VerifyIfLibrarian, controllers.CreateFeaturedCollection)
+	librarianRoute.Put("/featured/:id", middlewares.VerifyIfLibrarian, controllers.ModifyFeaturedCollection)
+	librarianRoute.Delete("/featured/:id", middlewares.VerifyIfLibrarian, controllers.DeleteFeaturedCollection)
+	librarianRoute.Put("/featured/:id/banner", middlewares.VerifyIfLibrarian, controllers.UploadFeaturedBanner)
+	librarianRoute.Post("/featured/:id/items", middlewares.VerifyIfLibrarian, controllers.AddFeaturedItem)
+	librarianRoute.Delete("/featured/:id/items/:itemId", middlewares.VerifyIfLibrarian, controllers.DeleteFeaturedItem)
+	librarianRoute.Put("/featured/:id/order", middlewares.VerifyIfLibrarian, controllers.ReorderFeaturedItems)
+
+	librarianRoute.Get("/copies/book/:bookId", middlewares.VerifyIfLibrarian, controllers.GetBookCopies)
+	librarianRoute.Post("/copies", middlewares.VerifyIfLibrarian, controllers.CreateCopy)
+	librarianRoute.Put("/copies/:id", middlewares.VerifyIfLibrarian, controllers.ModifyCopy)
+	librarianRoute.Delete("/copies/:id", middlewares.VerifyIfLibrarian, controllers.DeleteCopy)
+
+	librarianRoute.Post("/circulation/checkout", middlewares.VerifyIfLibrarian, controllers.CheckoutCopy)
+	librarianRoute.Post("/circulation/checkin", middlewares.VerifyIfLibrarian, controllers.CheckinCopy)
+	librarianRoute.Post("/circulation/renew/:loanId", middlewares.VerifyIfLibrarian, controllers.RenewLoan)
+	librarianRoute.Get("/circulation/loans", middlewares.VerifyIfLibrarian, controllers.GetLoans)
+	librarianRoute.Get("/circulation/transactions", middlewares.VerifyIfLibrarian, controllers.GetCirculationTransactions)
+	librarianRoute.Get("/circulation/overdue", middlewares.VerifyIfLibrarian, controllers.GetOverdueLoans)
+	librarianRoute.Get("/branches", middlewares.VerifyIfLibrarian, controllers.GetBranches)
+	librarianRoute.Get("/branches/:id/inventory", middlewares.VerifyIfLibrarian, controllers.GetBranchInventory)
+	librarianRoute.Get("/transfers", middlewares.VerifyIfLibrarian, controllers.GetTransfers)
+	librarianRoute.Post("/transfers", middlewares.VerifyIfLibrarian, controllers.CreateTransfer)
+	librarianRoute.Put("/transfers/:id/ship", middlewares.VerifyIfLibrarian, controllers.ShipTransfer)
+	librarianRoute.Put("/transfers/:id/receive", middlewares.VerifyIfLibrarian, controllers.ReceiveTransfer)
+	librarianRoute.Put("/transfers/:id/cancel", middlewares.VerifyIfLibrarian, controllers.CancelTransfer)
+
+	librarianRoute.Get("/holds/book/:bookId", middlewares.VerifyIfLibrarian, controllers.GetBookHoldQueue)
+
+	librarianRoute.Get("/accounts/:userId", middlewares.VerifyIfLibrarian, controllers.GetPatronAccount)
+	librarianRoute.Post("/accounts/:userId/payments", middlewares.VerifyIfLibrarian, controllers.RecordPatronPayment)
+	librarianRoute.Post("/accounts/:userId/waivers", middlewares.VerifyIfLibrarian, controllers.WaivePatronFine)
+
+	librarianRoute.Get("/labels/copy/:id/barcode", middlewares.VerifyIfLibrarian, controllers.GetCopyBarcode)
+	librarianRoute.Get("/labels/book/:id/barcode", middlewares.VerifyIfLibrarian, controllers.GetBookBarcode)
+	librarianRoute.Get("/labels/book/:id/qr", middlewares.VerifyIfLibrarian, controllers.GetBookQRCode)
+	librarianRoute.Post("/labels/sheet", middlewares.VerifyIfLibrarian, controllers.GetLabelSheet)
+
 }
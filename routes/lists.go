@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/catalinfl/readit-api/controllers"
+	"github.com/catalinfl/readit-api/middlewares"
+	"github.com/gofiber/fiber/v2"
+)
+
+func listsRoute(api fiber.Router) {
+	listRoute := api.Group("/lists")
+
+	listRoute.Get("/popular", controllers.GetPopularLists)
+	listRoute.Get("/followed", middlewares.VerifyLogin, controllers.GetFollowedLists)
+	listRoute.Get("/book/:bookId", controllers.GetListsForBook)
+	listRoute.Get("/user/:userId", controllers.GetUserLists)
+	listRoute.Get("/:id", controllers.GetList)
+
+	listRoute.Post("/", middlewares.VerifyLogin, controllers.CreateList)
+	listRoute.Put("/:id", middlewares.VerifyLogin, controllers.ModifyList)
+	listRoute.Delete("/:id", middlewares.VerifyLogin, controllers.DeleteList)
+
+	listRoute.Post("/:id/items", middlewares.VerifyLogin, controllers.AddListItem)
+	listRoute.Put("/:id/items/:itemId", middlewares.VerifyLogin, controllers.ModifyListItem)
+	listRoute.Delete("/:id/items/:itemId", middlewares.VerifyLogin, controllers.DeleteListItem)
+	listRoute.Put("/:id/order", middlewares.VerifyLogin, controllers.ReorderList)
+
+	listRoute.Post("/:id/like", middlewares.VerifyLogin, controllers.LikeList)
+	listRoute.Delete("/:id/like", middlewares.VerifyLogin, controllers.UnlikeList)
+	listRoute.Post("/:id/follow", middlewares.VerifyLogin, controllers.FollowList)
+	listRoute.Delete("/:id/follow", middlewares.VerifyLogin, controllers.UnfollowList)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/catalinfl/readit-api/controllers"
+	"github.com/catalinfl/readit-api/middlewares"
+	"github.com/gofiber/fiber/v2"
+)
+
+func quotesRoute(api fiber.Router) {
+	quoteRoute := api.Group("/quotes")
+
+	quoteRoute.Get("/mine", middlewares.VerifyLogin, controllers.GetMyQuotes)
+	quoteRoute.Get("/book/:bookId", controllers.GetBookQuotes)
+
+	quoteRoute.Post("/", middlewares.VerifyLogin, controllers.CreateQuote)
+	quoteRoute.Put("/:id", middlewares.VerifyLogin, controllers.ModifyQuote)
+	quoteRoute.Delete("/:id", middlewares.VerifyLogin, controllers.DeleteQuote)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/catalinfl/readit-api/controllers"
+	"github.com/catalinfl/readit-api/middlewares"
+	"github.com/gofiber/fiber/v2"
+)
+
+func reviewsRoute(api fiber.Router) {
+	reviewRoute := api.Group("/reviews")
+
+	reviewRoute.Get("/book/:bookId", controllers.GetBookReviews)
+	reviewRoute.Post("/book/:bookId", middlewares.VerifyLogin, controllers.CreateReview)
+
+	reviewRoute.Put("/:id", middlewares.VerifyLogin, controllers.ModifyReview)
+	reviewRoute.Delete("/:id", middlewares.VerifyLogin, controllers.DeleteReview)
+
+	reviewRoute.Post("/:id/helpful", middlewares.VerifyLogin, controllers.VoteReviewHelpful)
+	reviewRoute.Delete("/:id/helpful", middlewares.VerifyLogin, controllers.UnvoteReviewHelpful)
+
+	reviewRoute.Get("/:id/comments", controllers.GetReviewComments)
+	reviewRoute.Post("/:id/comments", middlewares.VerifyLogin, controllers.CreateReviewComment)
+	reviewRoute.Put("/comments/:commentId", middlewares.VerifyLogin, controllers.ModifyReviewComment)
+	reviewRoute.Delete("/comments/:commentId", middlewares.VerifyLogin, controllers.DeleteReviewComment)
+}
@@ -11,5 +11,11 @@ func Setup(app *fiber.App) {
 	usersRoute(api)
 	adminRoute(api)
 	librarianRoute(api)
+	reviewsRoute(api)
+	quotesRoute(api)
+	journalRoute(api)
+	listsRoute(api)
+	featuredRoute(api)
+	holdsRoute(api)
 
 }
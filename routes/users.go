@@ -21,6 +21,30 @@ func usersRoute(api fiber.Router) {
 	userRoute.Post("/send-friend-request/:id", middlewares.VerifyLogin, controllers.SendFriendRequest)
 	userRoute.Put("/accept-friend-request/:id", middlewares.VerifyLogin, controllers.AcceptFriendRequest)
 	userRoute.Delete("/reject-friend-request/:id", middlewares.VerifyLogin, controllers.RejectFriendRequest)
+	userRoute.Get("/recommendations", middlewares.VerifyLogin, controllers.GetRecommendations)
+	userRoute.Get("/export", middlewares.VerifyLogin, controllers.ExportUserData)
+	userRoute.Get("/loans", middlewares.VerifyLogin, controllers.GetMyLoans)
+	userRoute.Get("/account", middlewares.VerifyLogin, controllers.GetMyAccount)
+	userRoute.Get("/notifications", middlewares.VerifyLogin, controllers.GetNotifications)
+	userRoute.Put("/notifications/read", middlewares.VerifyLogin, controllers.MarkNotificationRead)
+	userRoute.Put("/notifications/:id/read", middlewares.VerifyLogin, controllers.MarkNotificationRead)
+	userRoute.Put("/timezone", middlewares.VerifyLogin, controllers.SetTimezone)
+	userRoute.Get("/goals", middlewares.VerifyLogin, controllers.GetMyGoals)
+	userRoute.Post("/goals", middlewares.VerifyLogin, controllers.CreateGoal)
+	userRoute.Put("/goals/:id", middlewares.VerifyLogin, controllers.ModifyGoal)
+	userRoute.Delete("/goals/:id", middlewares.VerifyLogin, controllers.DeleteGoal)
+	userRoute.Get("/ranks", controllers.GetRanks)
+	userRoute.Get("/badges", controllers.GetBadges)
+	userRoute.Get("/leaderboard", middlewares.VerifyLogin, controllers.GetLeaderboard)
+	userRoute.Put("/leaderboard-opt-out", middlewares.VerifyLogin, controllers.SetLeaderboardOptOut)
 	userRoute.Get("/:id", middlewares.VerifyLogin, controllers.GetUser)
+	userRoute.Get("/:id/goals", middlewares.VerifyLogin, controllers.GetUserGoals)
+	userRoute.Get("/:id/streak", middlewares.VerifyLogin, controllers.GetUserStreak)
+	userRoute.Get("/:id/heatmap", middlewares.VerifyLogin, controllers.GetUserHeatmap)
+	userRoute.Get("/:id/activity", middlewares.VerifyLogin, controllers.GetUserActivity)
+	userRoute.Get("/:id/stats", middlewares.VerifyLogin, controllers.GetUserStats)
+	userRoute.Get("/:id/year-in-review", middlewares.VerifyLogin, controllers.GetYearInReview)
+	userRoute.Get("/:id/rank", middlewares.VerifyLogin, controllers.GetUserRank)
+	userRoute.Get("/:id/badges", middlewares.VerifyLogin, controllers.GetUserBadges)
 
 }
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BadgeKinds describes what the threshold of each kind of badge counts.
+var BadgeKinds = map[string]string{
+	models.BadgeBooksFinished: "books finished",
+	models.BadgeGenreBooks:    "books finished in one genre",
+	models.BadgeLongBook:      "pages of the longest finished book",
+	models.BadgePagesRead:     "pages read",
+	models.BadgeStreak:        "days in a row",
+}
+
+type BadgeMetrics struct {
+	RankMetrics
+	LongestBook int
+	GenreBooks  map[string]int
+}
+
+type EarnedBadge struct {
+	models.BadgeDefinition
+	AwardedAt time.Time `json:"awarded_at"`
+}
+
+func ValidBadgeKind(kind string) bool {
+	_, ok := BadgeKinds[kind]
+
+	return ok
+}
+
+func BadgeDefinitions() []models.BadgeDefinition {
+	var badges []models.BadgeDefinition
+
+	db.GetDB().Order("kind, threshold, id").Find(&badges)
+
+	return badges
+}
+
+// UserBadgeMetrics adds what only badges look at to the rank metrics, genres
+// are compared case-insensitively.
+func UserBadgeMetrics(userID int, rankMetrics RankMetrics) BadgeMetrics {
+	metrics := BadgeMetrics{RankMetrics: rankMetrics, GenreBooks: make(map[string]int)}
+
+	finished := db.GetDB().Table("read_throughs").
+		Joins("JOIN books ON books.id = read_throughs.book_id").
+		Where("read_throughs.user_id = ? AND read_throughs.state = ?", userID, models.StateFinished)
+
+	finished.Session(&gorm.Session{}).Select("coalesce(max(books.pages), 0)").Scan(&metrics.LongestBook)
+
+	var genres []CountStat
+
+	finished.Session(&gorm.Session{}).
+		Select("lower(books.genre) AS name, count(*) AS count").
+		Where("books.genre <> ''").
+		Group("lower(books.genre)").
+		Scan(&genres)
+
+	for _, genre := range genres {
+		metrics.GenreBooks[genre.Name] = genre.Count
+	}
+
+	return metrics
+}
+
+func BadgeEarned(badge models.BadgeDefinition, metrics BadgeMetrics) bool {
+	switch badge.Kind {
+	case models.BadgeBooksFinished:
+		return metrics.BooksFinished >= badge.Threshold
+	case models.BadgeGenreBooks:
+		if badge.Genre != "" {
+			return metrics.GenreBooks[strings.ToLower(badge.Genre)] >= badge.Threshold
+		}
+
+		for _, count := range metrics.GenreBooks {
+			if count >= badge.Threshold {
+				return true
+			}
+		}
+
+		return false
+	case models.BadgeLongBook:
+		return metrics.LongestBook >= badge.Threshold
+	case models.BadgePagesRead:
+		return metrics.PagesRead >= badge.Threshold
+	case models.BadgeStreak:
+		return metrics.LongestStreak >= badge.Threshold
+	}
+
+	return false
+}
+
+// awardBadges gives a user every badge they earned and don't have yet, badges
+// are never taken back. Backfills don't notify, those users didn't just earn them.
+func awardBadges(userID int, badges []models.BadgeDefinition, metrics BadgeMetrics, notify bool) ([]models.BadgeDefinition, error) {
+	var owned []int
+
+	db.GetDB().Model(&models.UserBadge{}).Where("user_id = ?", userID).Pluck("badge_id", &owned)
+
+	has := make(map[int]bool, len(owned))
+
+	for _, id := range owned {
+		has[id] = true
+	}
+
+	awarded := []models.BadgeDefinition{}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		for _, badge := range badges {
+			if has[badge.ID] || !BadgeEarned(badge, metrics) {
+				continue
+			}
+
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserBadge{UserID: userID, BadgeID: badge.ID, AwardedAt: now})
+
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			awarded = append(awarded, badge)
+
+			if !notify {
+				continue
+			}
+
+			if err := Notify(tx, userID, "badge_awarded", fmt.Sprintf("You earned the %s badge", badge.Name)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return awarded, err
+}
+
+// BackfillBadges awards badges to every existing user, limited to one badge
+// when badgeID isn't zero. It returns how many badges were awarded.
+func BackfillBadges(badgeID int) (int, error) {
+	var badges []models.BadgeDefinition
+
+	query := db.GetDB().Order("id")
+
+	if badgeID > 0 {
+		query = query.Where("id = ?", badgeID)
+	}
+
+	query.Find(&badges)
+
+	if len(badges) == 0 {
+		return 0, nil
+	}
+
+	var ids []int
+
+	db.GetDB().Model(&models.User{}).Order("id").Pluck("id", &ids)
+
+	total := 0
+
+	for _, id := range ids {
+		awarded, err := awardBadges(id, badges, UserBadgeMetrics(id, UserRankMetrics(id)), false)
+
+		if err != nil {
+			return total, err
+		}
+
+		total += len(awarded)
+	}
+
+	return total, nil
+}
+
+// UserBadges lists the badges of a user from the latest, limit 0 means all.
+func UserBadges(userID int, limit int) []EarnedBadge {
+	badges := []EarnedBadge{}
+
+	query := db.GetDB().Table("user_badges").
+		Select("badge_definitions.*, user_badges.awarded_at").
+		Joins("JOIN badge_definitions ON badge_definitions.id = user_badges.badge_id").
+		Where("user_badges.user_id = ?", userID).
+		Order("user_badges.awarded_at desc, badge_definitions.id")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	query.Scan(&badges)
+
+	return badges
+}
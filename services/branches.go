@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrBranchNotFound   = errors.New("branch not found")
+	ErrSameBranch       = errors.New("copy is already at this branch")
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrTransferState    = errors.New("transfer can't move to this state")
+	ErrTransferPending  = errors.New("copy already has an open transfer")
+)
+
+func branchName(tx *gorm.DB, branchID int) string {
+	var branch models.Branch
+
+	tx.Where("id = ?", branchID).First(&branch)
+
+	return branch.Name
+}
+
+// RequestTransfer asks for a copy to be sent to another branch. The copy stays
+// on the shelf (and can still be lent) until the sending branch ships it.
+func RequestTransfer(copyID int, toBranchID int, librarianID int, note string) (models.TransferRequest, error) {
+	var transfer models.TransferRequest
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var bookCopy models.Copy
+
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", copyID).First(&bookCopy)
+
+		if bookCopy.ID == 0 {
+			return ErrCopyNotFound
+		}
+
+		if bookCopy.BranchID == nil {
+			return ErrBranchNotFound
+		}
+
+		if *bookCopy.BranchID == toBranchID {
+			return ErrSameBranch
+		}
+
+		if branchName(tx, toBranchID) == "" {
+			return ErrBranchNotFound
+		}
+
+		var open int64
+
+		tx.Model(&models.TransferRequest{}).
+			Where("copy_id = ? AND status IN ?", copyID, []string{models.TransferRequested, models.TransferInTransit}).
+			Count(&open)
+
+		if open > 0 {
+			return ErrTransferPending
+		}
+
+		transfer = models.TransferRequest{
+			CopyID:       copyID,
+			FromBranchID: *bookCopy.BranchID,
+			ToBranchID:   toBranchID,
+			RequestedBy:  librarianID,
+			Status:       models.TransferRequested,
+			Note:         note,
+		}
+
+		return tx.Create(&transfer).Error
+	})
+
+	return transfer, err
+}
+
+func lockTransfer(tx *gorm.DB, transferID int, status string) (models.TransferRequest, error) {
+	var transfer models.TransferRequest
+
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transferID).First(&transfer)
+
+	if transfer.ID == 0 {
+		return transfer, ErrTransferNotFound
+	}
+
+	if transfer.Status != status {
+		return transfer, ErrTransferState
+	}
+
+	return transfer, nil
+}
+
+// ShipTransfer takes the copy off the shelf of the sending branch, only copies
+// sitting on the shelf can leave.
+func ShipTransfer(transferID int, librarianID int) (models.TransferRequest, error) {
+	var transfer models.TransferRequest
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		transfer, err = lockTransfer(tx, transferID, models.TransferRequested)
+
+		if err != nil {
+			return err
+		}
+
+		var bookCopy models.Copy
+
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transfer.CopyID).First(&bookCopy)
+
+		if bookCopy.ID == 0 {
+			return ErrCopyNotFound
+		}
+
+		if bookCopy.Status != models.CopyAvailable {
+			return ErrCopyUnavailable
+		}
+
+		now := time.Now()
+
+		transfer.Status = models.TransferInTransit
+		transfer.ShippedAt = &now
+
+		if err := tx.Save(&transfer).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&bookCopy).Update("status", models.CopyInTransit).Error; err != nil {
+			return err
+		}
+
+		return logTransaction(tx, bookCopy.ID, nil, 0, librarianID, models.ActionTransfer, fmt.Sprintf("shipped to %s", branchName(tx, transfer.ToBranchID)))
+	})
+
+	return transfer, err
+}
+
+// ReceiveTransfer shelves the copy at its new branch, where it goes to the
+// first reader waiting in the holds queue if there is one.
+func ReceiveTransfer(transferID int, librarianID int) (models.TransferRequest, error) {
+	var transfer models.TransferRequest
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		transfer, err = lockTransfer(tx, transferID, models.TransferInTransit)
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		transfer.Status = models.TransferReceived
+		transfer.ReceivedAt = &now
+
+		if err := tx.Save(&transfer).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Copy{}).Where("id = ?", transfer.CopyID).Update("branch_id", transfer.ToBranchID).Error; err != nil {
+			return err
+		}
+
+		if err := logTransaction(tx, transfer.CopyID, nil, 0, librarianID, models.ActionTransfer, fmt.Sprintf("received at %s", branchName(tx, transfer.ToBranchID))); err != nil {
+			return err
+		}
+
+		return releaseCopy(tx, transfer.CopyID)
+	})
+
+	return transfer, err
+}
+
+func CancelTransfer(transferID int) (models.TransferRequest, error) {
+	var transfer models.TransferRequest
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		transfer, err = lockTransfer(tx, transferID, models.TransferRequested)
+
+		if err != nil {
+			return err
+		}
+
+		transfer.Status = models.TransferCancelled
+
+		return tx.Save(&transfer).Error
+	})
+
+	return transfer, err
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultLoanDays = 21
+	MaxLoanDays     = 60
+	RenewalDays     = 14
+	MaxRenewals     = 2
+)
+
+var (
+	ErrCopyNotFound    = errors.New("copy not found")
+	ErrCopyUnavailable = errors.New("copy is not available")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrLoanNotFound    = errors.New("loan not found")
+	ErrNoOpenLoan      = errors.New("copy is not checked out")
+	ErrRenewalLimit    = errors.New("renewal limit reached")
+)
+
+func logTransaction(tx *gorm.DB, copyID int, loanID *int, userID int, librarianID int, action string, note string) error {
+	return tx.Create(&models.CirculationTransaction{
+		CopyID:      copyID,
+		LoanID:      loanID,
+		UserID:      userID,
+		LibrarianID: librarianID,
+		Action:      action,
+		Note:        note,
+	}).Error
+}
+
+func lockCopyByBarcode(tx *gorm.DB, barcode string) (models.Copy, error) {
+	var bookCopy models.Copy
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("barcode = ?", barcode).First(&bookCopy).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return bookCopy, ErrCopyNotFound
+	}
+
+	return bookCopy, err
+}
+
+func Checkout(barcode string, userID int, librarianID int, days int) (models.Loan, error) {
+	var loan models.Loan
+
+	if days <= 0 {
+		days = DefaultLoanDays
+	}
+
+	if days > MaxLoanDays {
+		days = MaxLoanDays
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var user models.User
+
+		tx.Where("id = ?", userID).First(&user)
+
+		if user.ID == 0 {
+			return ErrUserNotFound
+		}
+
+		policy := CurrentFinePolicy(tx)
+
+		if policy.BlockThresholdCents > 0 && Balance(tx, userID) > policy.BlockThresholdCents {
+			return ErrFinesOutstanding
+		}
+
+		bookCopy, err := lockCopyByBarcode(tx, barcode)
+
+		if err != nil {
+			return err
+		}
+
+		switch bookCopy.Status {
+		case models.CopyAvailable:
+		case models.CopyOnHold:
+			var hold models.Hold
+
+			tx.Where("copy_id = ? AND status = ?", bookCopy.ID, models.HoldReady).First(&hold)
+
+			if hold.ID > 0 && hold.UserID != userID {
+				return ErrReservedForOther
+			}
+		default:
+			return ErrCopyUnavailable
+		}
+
+		now := time.Now()
+
+		loan = models.Loan{
+			CopyID:       bookCopy.ID,
+			BookID:       bookCopy.BookID,
+			BranchID:     bookCopy.BranchID,
+			UserID:       userID,
+			LibrarianID:  librarianID,
+			CheckedOutAt: now,
+			DueAt:        now.AddDate(0, 0, days),
+		}
+
+		if err := tx.Create(&loan).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&bookCopy).Update("status", models.CopyCheckedOut).Error; err != nil {
+			return err
+		}
+
+		if err := fulfilHolds(tx, userID, bookCopy); err != nil {
+			return err
+		}
+
+		return logTransaction(tx, bookCopy.ID, &loan.ID, userID, librarianID, models.ActionCheckout, fmt.Sprintf("due %s", loan.DueAt.Format("2006-01-02")))
+	})
+
+	return loan, err
+}
+
+// fulfilHolds closes the borrower's holds on the book: the hold the copy was
+// set aside for, or else one waiting hold. Copies set aside for the borrower's
+// other ready holds go to the next reader in the queue or back on the shelf.
+func fulfilHolds(tx *gorm.DB, userID int, bookCopy models.Copy) error {
+	var holds []models.Hold
+
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND book_id = ? AND status IN ?", userID, bookCopy.BookID, []string{models.HoldWaiting, models.HoldReady}).
+		Order("id").
+		Find(&holds)
+
+	fulfilled := 0
+
+	for _, hold := range holds {
+		if hold.Status == models.HoldReady && hold.CopyID != nil && *hold.CopyID == bookCopy.ID {
+			fulfilled = hold.ID
+		}
+	}
+
+	if fulfilled == 0 {
+		for _, hold := range holds {
+			if hold.Status == models.HoldWaiting {
+				fulfilled = hold.ID
+				break
+			}
+		}
+	}
+
+	for _, hold := range holds {
+		if hold.ID == fulfilled {
+			err := tx.Model(&hold).Updates(map[string]interface{}{"status": models.HoldFulfilled, "copy_id": bookCopy.ID}).Error
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if hold.Status != models.HoldReady || hold.CopyID == nil {
+			continue
+		}
+
+		err := tx.Model(&hold).Updates(map[string]interface{}{"status": models.HoldCancelled, "copy_id": nil, "ready_at": nil, "expires_at": nil}).Error
+
+		if err != nil {
+			return err
+		}
+
+		if err := releaseCopy(tx, *hold.CopyID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Checkin(barcode string, librarianID int) (models.Loan, error) {
+	var loan models.Loan
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		bookCopy, err := lockCopyByBarcode(tx, barcode)
+
+		if err != nil {
+			return err
+		}
+
+		tx.Where("copy_id = ? AND returned_at IS NULL", bookCopy.ID).First(&loan)
+
+		if loan.ID == 0 {
+			return ErrNoOpenLoan
+		}
+
+		now := time.Now()
+
+		loan.ReturnedAt = &now
+
+		if err := tx.Save(&loan).Error; err != nil {
+			return err
+		}
+
+		if err := assessLoanFine(tx, CurrentFinePolicy(tx), loan, now); err != nil {
+			return err
+		}
+
+		if err := releaseCopy(tx, bookCopy.ID); err != nil {
+			return err
+		}
+
+		note := ""
+
+		if now.After(loan.DueAt) {
+			note = "returned late"
+		}
+
+		return logTransaction(tx, bookCopy.ID, &loan.ID, loan.UserID, librarianID, models.ActionCheckin, note)
+	})
+
+	return loan, err
+}
+
+func Renew(loanID int, librarianID int) (models.Loan, error) {
+	var loan models.Loan
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", loanID).First(&loan)
+
+		if loan.ID == 0 {
+			return ErrLoanNotFound
+		}
+
+		if loan.ReturnedAt != nil {
+			return ErrNoOpenLoan
+		}
+
+		if loan.Renewals >= MaxRenewals {
+			return ErrRenewalLimit
+		}
+
+		var waiting int64
+
+		tx.Model(&models.Hold{}).Where("book_id = ? AND status = ?", loan.BookID, models.HoldWaiting).Count(&waiting)
+
+		if waiting > 0 {
+			return ErrHoldsWaiting
+		}
+
+		base := loan.DueAt
+
+		if now := time.Now(); now.After(base) {
+			base = now
+		}
+
+		loan.DueAt = base.AddDate(0, 0, RenewalDays)
+		loan.Renewals++
+
+		if err := tx.Save(&loan).Error; err != nil {
+			return err
+		}
+
+		return logTransaction(tx, loan.CopyID, &loan.ID, loan.UserID, librarianID, models.ActionRenew, fmt.Sprintf("due %s", loan.DueAt.Format("2006-01-02")))
+	})
+
+	return loan, err
+}
+
+// SetCopyStatus is used for manual status changes (lost, maintenance, back
+// on the shelf); copies that are on loan must go through Checkin instead.
+func SetCopyStatus(copyID int, status string, librarianID int, note string) (models.Copy, error) {
+	var bookCopy models.Copy
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", copyID).First(&bookCopy)
+
+		if bookCopy.ID == 0 {
+			return ErrCopyNotFound
+		}
+
+		if bookCopy.Status == models.CopyCheckedOut && status != models.CopyLost {
+			return ErrCopyUnavailable
+		}
+
+		if bookCopy.Status == models.CopyInTransit {
+			if status != models.CopyLost {
+				return ErrCopyUnavailable
+			}
+
+			err := tx.Model(&models.TransferRequest{}).
+				Where("copy_id = ? AND status = ?", bookCopy.ID, models.TransferInTransit).
+				Update("status", models.TransferCancelled).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if bookCopy.Status == models.CopyOnHold {
+			// the reader keeps their turn, they will get the next copy back
+			err := tx.Model(&models.Hold{}).
+				Where("copy_id = ? AND status = ?", bookCopy.ID, models.HoldReady).
+				Updates(map[string]interface{}{"status": models.HoldWaiting, "copy_id": nil, "ready_at": nil, "expires_at": nil}).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		var loan models.Loan
+
+		if bookCopy.Status == models.CopyCheckedOut {
+			// the copy is lost, its loan can't be checked in anymore
+			tx.Where("copy_id = ? AND returned_at IS NULL", bookCopy.ID).First(&loan)
+
+			if loan.ID > 0 {
+				now := time.Now()
+
+				loan.ReturnedAt = &now
+
+				if err := tx.Save(&loan).Error; err != nil {
+					return err
+				}
+
+				if err := chargeLostLoan(tx, loan, now); err != nil {
+					return err
+				}
+			}
+		}
+
+		var loanID *int
+
+		if loan.ID > 0 {
+			loanID = &loan.ID
+		}
+
+		if err := logTransaction(tx, bookCopy.ID, loanID, loan.UserID, librarianID, models.ActionStatus, strings.TrimSpace(status+" "+note)); err != nil {
+			return err
+		}
+
+		if status == models.CopyAvailable {
+			return releaseCopy(tx, bookCopy.ID)
+		}
+
+		return tx.Model(&bookCopy).Update("status", status).Error
+	})
+
+	return bookCopy, err
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	refreshMu sync.Mutex
+	// users whose ranks, badges and leaderboards are being refreshed, true
+	// when another event came in meanwhile and the refresh must run again
+	refreshing = make(map[int]bool)
+)
+
+// ReadingEvent is called after anything that changes what a user has read:
+// progress, state changes, re-reads, shelf changes and ratings. Stats are
+// dropped right away, the rest is refreshed in the background so saving
+// progress doesn't wait for it.
+func ReadingEvent(userID int) {
+	if userID == 0 {
+		return
+	}
+
+	InvalidateUserStats(userID)
+
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	// events for a user are coalesced, one refresh at a time per user
+	if _, ok := refreshing[userID]; ok {
+		refreshing[userID] = true
+		return
+	}
+
+	refreshing[userID] = false
+
+	go refreshUser(userID)
+}
+
+func refreshUser(userID int) {
+	for {
+		refreshReadingMetrics(userID)
+
+		refreshMu.Lock()
+
+		if !refreshing[userID] {
+			delete(refreshing, userID)
+			refreshMu.Unlock()
+			return
+		}
+
+		refreshing[userID] = false
+		refreshMu.Unlock()
+	}
+}
+
+func refreshReadingMetrics(userID int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("Refreshing reading metrics of user", userID, "failed:", r)
+		}
+	}()
+
+	// the streak is the costly part, it is computed once for ranks and badges
+	metrics := UserRankMetrics(userID)
+
+	if err := evaluateRank(userID, RankDefinitions(), metrics, true); err != nil {
+		fmt.Println("Failed to evaluate rank of user", userID, err)
+	}
+
+	if _, err := awardBadges(userID, BadgeDefinitions(), UserBadgeMetrics(userID, metrics), true); err != nil {
+		fmt.Println("Failed to award badges to user", userID, err)
+	}
+
+	if err := UpdateLeaderboards(userID); err != nil {
+		fmt.Println("Failed to update leaderboards of user", userID, err)
+	}
+}
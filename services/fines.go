@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+)
+
+var DefaultFinePolicy = models.FinePolicy{
+	PerDayCents:         25,
+	GraceDays:           2,
+	MaxFineCents:        1000,
+	BlockThresholdCents: 500,
+	LostFeeCents:        2500,
+}
+
+var (
+	ErrFinesOutstanding = errors.New("patron has outstanding fines")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+func CurrentFinePolicy(tx *gorm.DB) models.FinePolicy {
+	var policy models.FinePolicy
+
+	tx.Order("id").First(&policy)
+
+	if policy.ID == 0 {
+		return DefaultFinePolicy
+	}
+
+	return policy
+}
+
+func SaveFinePolicy(policy models.FinePolicy) (models.FinePolicy, error) {
+	var current models.FinePolicy
+
+	db.GetDB().Order("id").First(&current)
+
+	policy.ID = current.ID
+
+	err := db.GetDB().Save(&policy).Error
+
+	return policy, err
+}
+
+// ComputeFine charges every day late past the grace period, capped by the
+// policy maximum (a zero maximum means no cap).
+func ComputeFine(policy models.FinePolicy, dueAt time.Time, until time.Time) int {
+	if !until.After(dueAt) {
+		return 0
+	}
+
+	daysLate := int(until.Sub(dueAt).Hours() / 24)
+
+	charged := daysLate - policy.GraceDays
+
+	if charged <= 0 {
+		return 0
+	}
+
+	fine := charged * policy.PerDayCents
+
+	if policy.MaxFineCents > 0 && fine > policy.MaxFineCents {
+		fine = policy.MaxFineCents
+	}
+
+	return fine
+}
+
+func Balance(tx *gorm.DB, userID int) int {
+	var balance int
+
+	tx.Model(&models.AccountEntry{}).Where("user_id = ?", userID).Select("coalesce(sum(amount_cents), 0)").Scan(&balance)
+
+	return balance
+}
+
+// assessLoanFine keeps a single fine entry per loan in sync with how late the
+// loan is, so running it every day (or at check in) never double charges.
+func assessLoanFine(tx *gorm.DB, policy models.FinePolicy, loan models.Loan, until time.Time) error {
+	amount := ComputeFine(policy, loan.DueAt, until)
+
+	if amount == 0 {
+		return nil
+	}
+
+	var entry models.AccountEntry
+
+	tx.Where("loan_id = ? AND kind = ?", loan.ID, models.EntryFine).First(&entry)
+
+	if entry.ID == 0 {
+		entry = models.AccountEntry{
+			UserID:      loan.UserID,
+			LoanID:      &loan.ID,
+			Kind:        models.EntryFine,
+			AmountCents: amount,
+			Note:        fmt.Sprintf("overdue since %s", loan.DueAt.Format("2006-01-02")),
+		}
+
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		return Notify(tx, loan.UserID, "loan_overdue", fmt.Sprintf("A borrowed book was due on %s, please return it", loan.DueAt.Format("2006-01-02")))
+	}
+
+	if entry.AmountCents == amount {
+		return nil
+	}
+
+	return tx.Model(&entry).Update("amount_cents", amount).Error
+}
+
+// chargeLostLoan settles a loan whose copy was lost: the overdue fine stops
+// where it is and the replacement fee is added to the patron's ledger.
+func chargeLostLoan(tx *gorm.DB, loan models.Loan, lostAt time.Time) error {
+	policy := CurrentFinePolicy(tx)
+
+	if err := assessLoanFine(tx, policy, loan, lostAt); err != nil {
+		return err
+	}
+
+	if policy.LostFeeCents == 0 {
+		return nil
+	}
+
+	entry := models.AccountEntry{
+		UserID:      loan.UserID,
+		LoanID:      &loan.ID,
+		Kind:        models.EntryLost,
+		AmountCents: policy.LostFeeCents,
+		Note:        "replacement of a lost copy",
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	return Notify(tx, loan.UserID, "loan_lost", fmt.Sprintf("A borrowed book was marked as lost, a replacement fee of %d.%02d was added to your account", policy.LostFeeCents/100, policy.LostFeeCents%100))
+}
+
+func AssessOverdueLoans() {
+	policy := CurrentFinePolicy(db.GetDB())
+
+	var loans []models.Loan
+
+	db.GetDB().Where("returned_at IS NULL AND due_at < ?", time.Now()).Find(&loans)
+
+	now := time.Now()
+
+	for _, loan := range loans {
+		db.GetDB().Transaction(func(tx *gorm.DB) error {
+			return assessLoanFine(tx, policy, loan, now)
+		})
+	}
+}
+
+func RecordPayment(userID int, librarianID int, kind string, amount int, loanID *int, note string) (models.AccountEntry, error) {
+	var entry models.AccountEntry
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		balance := Balance(tx, userID)
+
+		if amount <= 0 || amount > balance {
+			return ErrInvalidAmount
+		}
+
+		entry = models.AccountEntry{
+			UserID:      userID,
+			LoanID:      loanID,
+			Kind:        kind,
+			AmountCents: -amount,
+			Note:        note,
+			LibrarianID: librarianID,
+		}
+
+		return tx.Create(&entry).Error
+	})
+
+	return entry, err
+}
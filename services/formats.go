@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/catalinfl/readit-api/models"
+)
+
+var (
+	ErrUnknownFormat    = errors.New("unknown format, use one of paper, ebook, audiobook")
+	ErrNoAudioDuration  = errors.New("this book has no audiobook duration yet")
+	ErrNegativeProgress = errors.New("progress can't be negative")
+)
+
+const (
+	// a narrator reads about 150 words a minute, a printed page holds about 300
+	audioPagesPerMinute = 0.5
+	// length assumed for ebooks whose page count isn't known
+	defaultEbookPages = 300
+)
+
+// FormatUnits is the native progress unit of each format.
+var FormatUnits = map[string]string{
+	models.FormatPaper:     "pages",
+	models.FormatEbook:     "percent",
+	models.FormatAudiobook: "minutes",
+}
+
+func ValidFormat(format string) bool {
+	_, ok := FormatUnits[format]
+
+	return ok
+}
+
+func formatOrPaper(format string) string {
+	if format == "" {
+		return models.FormatPaper
+	}
+
+	return format
+}
+
+// FormatTotal is the native progress of a finished book in a format, zero when
+// the book doesn't say how long it is.
+func FormatTotal(format string, book models.Book) float64 {
+	switch formatOrPaper(format) {
+	case models.FormatEbook:
+		return 100
+	case models.FormatAudiobook:
+		return float64(book.AudioMinutes)
+	default:
+		return float64(book.Pages)
+	}
+}
+
+// CheckFormat tells whether progress in a format can be tracked for a book.
+func CheckFormat(format string, book models.Book) error {
+	if !ValidFormat(formatOrPaper(format)) {
+		return ErrUnknownFormat
+	}
+
+	if format == models.FormatAudiobook && book.AudioMinutes == 0 {
+		return ErrNoAudioDuration
+	}
+
+	return nil
+}
+
+// CheckProgress validates a native progress value against the book's length.
+func CheckProgress(format string, book models.Book, progress float64) error {
+	if progress < 0 {
+		return ErrNegativeProgress
+	}
+
+	if total := FormatTotal(format, book); total > 0 && progress > total {
+		return fmt.Errorf("progress can't be more than %s %s", formatNumber(total), FormatUnits[formatOrPaper(format)])
+	}
+
+	return nil
+}
+
+// ProgressPercent normalizes native progress to a percentage of the book,
+// rounded down so that only a finished book shows 100.
+func ProgressPercent(format string, book models.Book, progress float64) float64 {
+	total := FormatTotal(format, book)
+
+	if total <= 0 {
+		return 0
+	}
+
+	return math.Min(100, math.Floor(progress*1000/total)/10)
+}
+
+// pageLength is the number of pages a book counts for in a format. Without a
+// page count, audiobooks are estimated from their running time and ebooks get
+// an average length, so their readers still log pages.
+func pageLength(format string, book models.Book) float64 {
+	if book.Pages > 0 {
+		return float64(book.Pages)
+	}
+
+	switch format {
+	case models.FormatAudiobook:
+		return math.Round(float64(book.AudioMinutes) * audioPagesPerMinute)
+	case models.FormatEbook:
+		return defaultEbookPages
+	}
+
+	return 0
+}
+
+// PageEquivalent converts native progress to pages, which is what sessions,
+// goals, streaks and stats count. Paper books whose length isn't known keep
+// their page count.
+func PageEquivalent(format string, book models.Book, progress float64) uint {
+	if formatOrPaper(format) == models.FormatPaper {
+		return uint(math.Round(progress))
+	}
+
+	length := pageLength(format, book)
+	pages := math.Round(ProgressPercent(format, book, progress) * length / 100)
+
+	return uint(math.Min(pages, length))
+}
+
+// ConvertProgress carries progress over to another format through its
+// percentage, so switching from paper to the audiobook keeps your place.
+func ConvertProgress(userBook models.UserBooks, book models.Book, format string) float64 {
+	from := formatOrPaper(userBook.Format)
+
+	if from == format {
+		return userBook.Progress
+	}
+
+	total := FormatTotal(format, book)
+
+	if FormatTotal(from, book) <= 0 || total <= 0 {
+		// no length to convert through, only pages can be kept as they are
+		if format == models.FormatPaper {
+			return float64(userBook.PagesRead)
+		}
+
+		return 0
+	}
+
+	progress := userBook.Percent * total / 100
+
+	if format == models.FormatEbook {
+		return roundTo(progress, 1)
+	}
+
+	return math.Round(progress)
+}
+
+// SetProgress stores native progress on a user book along with its percentage.
+func SetProgress(userBook *models.UserBooks, book models.Book, progress float64) {
+	userBook.Progress = progress
+	userBook.Percent = ProgressPercent(userBook.Format, book, progress)
+}
+
+func formatNumber(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+
+	return fmt.Sprintf("%.1f", value)
+}
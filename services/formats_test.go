@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/catalinfl/readit-api/models"
+)
+
+func TestPageEquivalent(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		book     models.Book
+		progress float64
+		want     uint
+	}{
+		{name: "paper", format: models.FormatPaper, book: models.Book{Pages: 300}, progress: 120, want: 120},
+		{name: "paper without length", format: models.FormatPaper, progress: 42, want: 42},
+		{name: "ebook", format: models.FormatEbook, book: models.Book{Pages: 400}, progress: 25, want: 100},
+		{name: "ebook without length", format: models.FormatEbook, progress: 50, want: 150},
+		{name: "audiobook", format: models.FormatAudiobook, book: models.Book{Pages: 300, AudioMinutes: 600}, progress: 300, want: 150},
+		{name: "audiobook without page count", format: models.FormatAudiobook, book: models.Book{AudioMinutes: 600}, progress: 600, want: 300},
+		{name: "audiobook without duration", format: models.FormatAudiobook, progress: 60, want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := PageEquivalent(test.format, test.book, test.progress); got != test.want {
+				t.Errorf("PageEquivalent() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+func AreFriends(userID int, otherID int) bool {
+	if userID == 0 || otherID == 0 {
+		return false
+	}
+
+	var count int64
+
+	db.GetDB().Model(&models.Friends{}).
+		Where("((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)) AND status = ?", userID, otherID, otherID, userID, "accepted").
+		Count(&count)
+
+	return count > 0
+}
+
+func FriendIDs(userID int) []int {
+	var friends []models.Friends
+
+	db.GetDB().Where("(sender_id = ? OR receiver_id = ?) AND status = ?", userID, userID, "accepted").Find(&friends)
+
+	ids := make([]int, 0, len(friends))
+
+	for _, friend := range friends {
+		if friend.SenderID == userID {
+			ids = append(ids, friend.ReceiverID)
+		} else {
+			ids = append(ids, friend.SenderID)
+		}
+	}
+
+	return ids
+}
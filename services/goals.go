@@ -0,0 +1,107 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+const (
+	GoalNotStarted = "not_started"
+	GoalAhead      = "ahead"
+	GoalOnTrack    = "on_track"
+	GoalBehind     = "behind"
+	GoalCompleted  = "completed"
+	GoalMissed     = "missed"
+)
+
+type GoalProgress struct {
+	models.ReadingGoal
+	Done     int       `json:"done"`
+	Expected float64   `json:"expected"`
+	Percent  float64   `json:"percent"`
+	PerDay   float64   `json:"per_day"`
+	Status   string    `json:"status"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// GoalPeriod returns the start (inclusive) and end (exclusive) of a goal in
+// the given location.
+func GoalPeriod(goal models.ReadingGoal, loc *time.Location) (time.Time, time.Time) {
+	if goal.Month == 0 {
+		start := time.Date(goal.Year, time.January, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0)
+	}
+
+	start := time.Date(goal.Year, time.Month(goal.Month), 1, 0, 0, 0, 0, loc)
+
+	return start, start.AddDate(0, 1, 0)
+}
+
+// GoalDone counts what the user read inside a period: finished read-throughs
+// for book goals and pages from reading sessions for page goals.
+func GoalDone(userID int, kind string, start time.Time, end time.Time) int {
+	var done int64
+
+	if kind == models.GoalPages {
+		db.GetDB().Model(&models.ReadingSession{}).
+			Select("coalesce(sum(pages), 0)").
+			Where("user_id = ? AND read_at >= ? AND read_at < ?", userID, start, end).
+			Scan(&done)
+	} else {
+		db.GetDB().Model(&models.ReadThrough{}).
+			Where("user_id = ? AND state = ? AND finished_at >= ? AND finished_at < ?", userID, models.StateFinished, start, end).
+			Count(&done)
+	}
+
+	return int(done)
+}
+
+// Progress compares what was read so far with where a reader going at a
+// steady pace would be right now.
+func Progress(goal models.ReadingGoal, loc *time.Location, now time.Time) GoalProgress {
+	start, end := GoalPeriod(goal, loc)
+
+	progress := GoalProgress{
+		ReadingGoal: goal,
+		Done:        GoalDone(goal.UserID, goal.Kind, start, end),
+		StartsAt:    start,
+		EndsAt:      end,
+	}
+
+	if goal.Target > 0 {
+		progress.Percent = math.Round(float64(progress.Done)*1000/float64(goal.Target)) / 10
+	}
+
+	elapsed := now.Sub(start).Seconds() / end.Sub(start).Seconds()
+	elapsed = math.Max(0, math.Min(1, elapsed))
+
+	progress.Expected = math.Round(float64(goal.Target)*elapsed*10) / 10
+
+	if daysLeft := end.Sub(now).Hours() / 24; daysLeft > 0 && progress.Done < goal.Target {
+		progress.PerDay = math.Round(float64(goal.Target-progress.Done)/math.Ceil(daysLeft)*10) / 10
+	}
+
+	// a couple of percent either way still counts as being on schedule
+	tolerance := math.Max(0.5, float64(goal.Target)*0.02)
+
+	switch {
+	case progress.Done >= goal.Target:
+		progress.Status = GoalCompleted
+	case !now.Before(end):
+		progress.Status = GoalMissed
+	case now.Before(start):
+		progress.Status = GoalNotStarted
+	case float64(progress.Done) > progress.Expected+tolerance:
+		progress.Status = GoalAhead
+	case float64(progress.Done) < progress.Expected-tolerance:
+		progress.Status = GoalBehind
+	default:
+		progress.Status = GoalOnTrack
+	}
+
+	return progress
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const HoldPickupDays = 3
+
+var (
+	ErrBookNotFound     = errors.New("book not found")
+	ErrHoldNotFound     = errors.New("hold not found")
+	ErrAlreadyOnHold    = errors.New("book is already on hold")
+	ErrAlreadyBorrowed  = errors.New("book is already borrowed")
+	ErrHoldNotActive    = errors.New("hold is not active")
+	ErrReservedForOther = errors.New("copy is reserved for another reader")
+	ErrHoldsWaiting     = errors.New("other readers are waiting for this book")
+)
+
+func PlaceHold(userID int, bookID int) (models.Hold, error) {
+	var hold models.Hold
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var book models.Book
+
+		tx.Where("id = ?", bookID).First(&book)
+
+		if book.ID == 0 {
+			return ErrBookNotFound
+		}
+
+		var active int64
+
+		tx.Model(&models.Hold{}).Where("user_id = ? AND book_id = ? AND status IN ?", userID, bookID, []string{models.HoldWaiting, models.HoldReady}).Count(&active)
+
+		if active > 0 {
+			return ErrAlreadyOnHold
+		}
+
+		var borrowed int64
+
+		tx.Model(&models.Loan{}).Where("user_id = ? AND book_id = ? AND returned_at IS NULL", userID, bookID).Count(&borrowed)
+
+		if borrowed > 0 {
+			return ErrAlreadyBorrowed
+		}
+
+		hold = models.Hold{
+			BookID: bookID,
+			UserID: userID,
+			Status: models.HoldWaiting,
+		}
+
+		if err := tx.Create(&hold).Error; err != nil {
+			return err
+		}
+
+		// a copy may be sitting on the shelf already, hand it to the queue
+		var available []models.Copy
+
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("book_id = ? AND status = ?", bookID, models.CopyAvailable).Find(&available)
+
+		for _, bookCopy := range available {
+			assigned, err := assignCopyToNextHold(tx, bookCopy)
+
+			if err != nil {
+				return err
+			}
+
+			if !assigned {
+				break
+			}
+		}
+
+		return tx.Where("id = ?", hold.ID).First(&hold).Error
+	})
+
+	return hold, err
+}
+
+func CancelHold(holdID int, userID int) (models.Hold, error) {
+	var hold models.Hold
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", holdID).First(&hold)
+
+		if hold.ID == 0 || hold.UserID != userID {
+			return ErrHoldNotFound
+		}
+
+		if hold.Status != models.HoldWaiting && hold.Status != models.HoldReady {
+			return ErrHoldNotActive
+		}
+
+		wasReady := hold.Status == models.HoldReady
+
+		hold.Status = models.HoldCancelled
+
+		if err := tx.Save(&hold).Error; err != nil {
+			return err
+		}
+
+		if wasReady && hold.CopyID != nil {
+			return releaseCopy(tx, *hold.CopyID)
+		}
+
+		return nil
+	})
+
+	return hold, err
+}
+
+// HoldPosition is the 1-based place of a waiting hold in its book's queue,
+// holds that are not waiting anymore have no position.
+func HoldPosition(hold models.Hold) int64 {
+	if hold.Status != models.HoldWaiting {
+		return 0
+	}
+
+	var ahead int64
+
+	db.GetDB().Model(&models.Hold{}).Where("book_id = ? AND status = ? AND id < ?", hold.BookID, models.HoldWaiting, hold.ID).Count(&ahead)
+
+	return ahead + 1
+}
+
+// assignCopyToNextHold gives a copy to the oldest waiting hold of its book.
+// It reports false when nobody is waiting so the caller can shelve the copy.
+func assignCopyToNextHold(tx *gorm.DB, bookCopy models.Copy) (bool, error) {
+	var hold models.Hold
+
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", bookCopy.BookID, models.HoldWaiting).
+		Order("id").
+		First(&hold)
+
+	if hold.ID == 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	expires := now.AddDate(0, 0, HoldPickupDays)
+
+	hold.Status = models.HoldReady
+	hold.CopyID = &bookCopy.ID
+	hold.ReadyAt = &now
+	hold.ExpiresAt = &expires
+
+	if err := tx.Save(&hold).Error; err != nil {
+		return false, err
+	}
+
+	if err := tx.Model(&bookCopy).Update("status", models.CopyOnHold).Error; err != nil {
+		return false, err
+	}
+
+	if err := logTransaction(tx, bookCopy.ID, nil, hold.UserID, 0, models.ActionHold, fmt.Sprintf("ready for hold %d", hold.ID)); err != nil {
+		return false, err
+	}
+
+	var book models.Book
+
+	tx.Where("id = ?", bookCopy.BookID).First(&book)
+
+	return true, Notify(tx, hold.UserID, "hold_ready", fmt.Sprintf("\"%s\" is waiting for you, pick it up before %s", book.Title, expires.Format("2006-01-02")))
+}
+
+// releaseCopy is called whenever a copy comes back to the library, either
+// returned by a reader or freed by a cancelled/expired hold.
+func releaseCopy(tx *gorm.DB, copyID int) error {
+	var bookCopy models.Copy
+
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", copyID).First(&bookCopy)
+
+	if bookCopy.ID == 0 {
+		return ErrCopyNotFound
+	}
+
+	assigned, err := assignCopyToNextHold(tx, bookCopy)
+
+	if err != nil || assigned {
+		return err
+	}
+
+	return tx.Model(&bookCopy).Update("status", models.CopyAvailable).Error
+}
+
+func ExpireHolds() {
+	var holds []models.Hold
+
+	db.GetDB().Where("status = ? AND expires_at < ?", models.HoldReady, time.Now()).Find(&holds)
+
+	for _, hold := range holds {
+		db.GetDB().Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Hold{}).Where("id = ? AND status = ?", hold.ID, models.HoldReady).Update("status", models.HoldExpired)
+
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			if err := Notify(tx, hold.UserID, "hold_expired", "Your hold expired because it wasn't picked up in time"); err != nil {
+				return err
+			}
+
+			if hold.CopyID == nil {
+				return nil
+			}
+
+			return releaseCopy(tx, *hold.CopyID)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func()
+}
+
+var jobs = []job{
+	{name: "trending", interval: 10 * time.Minute, run: RefreshTrending},
+	{name: "holds", interval: time.Hour, run: ExpireHolds},
+	{name: "fines", interval: time.Hour, run: AssessOverdueLoans},
+	{name: "leaderboards", interval: 24 * time.Hour, run: RefreshLeaderboards},
+}
+
+// StartJobs runs every background job once and then on its own ticker.
+// It must be called after the database connection is established.
+func StartJobs() {
+	for _, j := range jobs {
+		go func(j job) {
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+
+			for {
+				runJob(j)
+				<-ticker.C
+			}
+		}(j)
+	}
+
+	fmt.Println("Background jobs started")
+}
+
+func runJob(j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("Job", j.name, "failed:", r)
+		}
+	}()
+
+	j.run()
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaderboardMetrics maps a leaderboard metric to its entry column.
+var LeaderboardMetrics = map[string]string{
+	"pages": "pages",
+	"books": "books",
+}
+
+var LeaderboardPeriods = []string{models.PeriodWeek, models.PeriodMonth, models.PeriodYear}
+
+type LeaderboardRow struct {
+	Position   int    `json:"position"`
+	UserID     int    `json:"user_id"`
+	Name       string `json:"name"`
+	ProfilePic string `json:"profile_pic"`
+	Rank       string `json:"rank"`
+	Value      int    `json:"value"`
+}
+
+func ValidLeaderboardPeriod(period string) bool {
+	for _, p := range LeaderboardPeriods {
+		if p == period {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PeriodStart is the start of the window containing now. Boards are shared
+// by readers everywhere, so windows are in UTC and weeks start on Monday.
+func PeriodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case models.PeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case models.PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func periodEnd(period string, start time.Time) time.Time {
+	switch period {
+	case models.PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case models.PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(1, 0, 0)
+	}
+}
+
+// UpdateLeaderboards recounts one user's current windows, which only sums that
+// user's sessions and read-throughs for the year.
+func UpdateLeaderboards(userID int) error {
+	now := time.Now()
+
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, period := range LeaderboardPeriods {
+			start := PeriodStart(period, now)
+			end := periodEnd(period, start)
+
+			entry := models.LeaderboardEntry{UserID: userID, Period: period, PeriodStart: start}
+
+			tx.Model(&models.ReadingSession{}).
+				Select("coalesce(sum(pages), 0)").
+				Where("user_id = ? AND read_at >= ? AND read_at < ?", userID, start, end).
+				Scan(&entry.Pages)
+
+			var books int64
+
+			tx.Model(&models.ReadThrough{}).
+				Where("user_id = ? AND state = ? AND finished_at >= ? AND finished_at < ?", userID, models.StateFinished, start, end).
+				Count(&books)
+
+			entry.Books = int(books)
+
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "period"}, {Name: "period_start"}},
+				DoUpdates: clause.AssignmentColumns([]string{"pages", "books", "updated_at"}),
+			}).Create(&entry).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RefreshLeaderboards drops windows older than last year and recounts the
+// readers active this year, so a new week or month starts from real numbers
+// and boards are filled right after deploying.
+func RefreshLeaderboards() {
+	now := time.Now()
+	yearStart := PeriodStart(models.PeriodYear, now)
+
+	db.GetDB().Where("period_start < ?", yearStart.AddDate(-1, 0, 0)).Delete(&models.LeaderboardEntry{})
+
+	var ids []int
+
+	db.GetDB().Raw(`SELECT user_id FROM reading_sessions WHERE read_at >= ?
+		UNION SELECT user_id FROM read_throughs WHERE state = ? AND finished_at >= ?`,
+		yearStart, models.StateFinished, yearStart).Scan(&ids)
+
+	for _, id := range ids {
+		if err := UpdateLeaderboards(id); err != nil {
+			fmt.Println("Failed to update leaderboards of user", id, err)
+		}
+	}
+}
+
+func leaderboardQuery(metric string, period string, userIDs []int) *gorm.DB {
+	column := "leaderboard_entries." + LeaderboardMetrics[metric]
+
+	board := db.GetDB().Table("leaderboard_entries").
+		Select("leaderboard_entries.user_id, users.name, users.profile_pic, users.rank, "+column+" AS value, RANK() OVER (ORDER BY "+column+" DESC) AS position").
+		Joins("JOIN users ON users.id = leaderboard_entries.user_id").
+		Where("leaderboard_entries.period = ? AND leaderboard_entries.period_start = ?", period, PeriodStart(period, time.Now())).
+		Where("users.leaderboard_opt_out = ? AND "+column+" > 0", false)
+
+	if userIDs != nil {
+		board = board.Where("leaderboard_entries.user_id IN ?", userIDs)
+	}
+
+	return db.GetDB().Table("(?) AS board", board)
+}
+
+// Leaderboard ranks readers of the current window by metric, everyone when
+// userIDs is nil or only those users. Readers who opted out never show up.
+func Leaderboard(metric string, period string, userIDs []int, offset int, limit int) []LeaderboardRow {
+	rows := []LeaderboardRow{}
+
+	leaderboardQuery(metric, period, userIDs).Order("position, name").Offset(offset).Limit(limit).Scan(&rows)
+
+	return rows
+}
+
+// LeaderboardPosition is where a user stands on a board, nil when they aren't on it.
+func LeaderboardPosition(metric string, period string, userIDs []int, userID int) *LeaderboardRow {
+	var rows []LeaderboardRow
+
+	leaderboardQuery(metric, period, userIDs).Where("user_id = ?", userID).Scan(&rows)
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return &rows[0]
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+)
+
+func Notify(tx *gorm.DB, userID int, kind string, message string) error {
+	return tx.Create(&models.Notification{
+		UserID:  userID,
+		Kind:    kind,
+		Message: message,
+	}).Error
+}
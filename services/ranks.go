@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+)
+
+type RankMetrics struct {
+	BooksFinished int `json:"books_finished"`
+	PagesRead     int `json:"pages_read"`
+	LongestStreak int `json:"longest_streak"`
+}
+
+// UserRankMetrics counts what ranks are based on: finished read-throughs (so
+// re-reads count), pages from sessions and the longest streak ever, which
+// keeps a rank from dropping when a streak breaks.
+func UserRankMetrics(userID int) RankMetrics {
+	var metrics RankMetrics
+
+	var books int64
+
+	db.GetDB().Model(&models.ReadThrough{}).Where("user_id = ? AND state = ?", userID, models.StateFinished).Count(&books)
+
+	metrics.BooksFinished = int(books)
+
+	db.GetDB().Model(&models.ReadingSession{}).Select("coalesce(sum(pages), 0)").Where("user_id = ?", userID).Scan(&metrics.PagesRead)
+
+	metrics.LongestStreak = UserStreak(userID).Longest
+
+	return metrics
+}
+
+func RankDefinitions() []models.RankDefinition {
+	var ranks []models.RankDefinition
+
+	db.GetDB().Order("level desc, id").Find(&ranks)
+
+	return ranks
+}
+
+// MatchRank picks the highest rank whose minimums are all met, ranks must be
+// sorted by level from the highest.
+func MatchRank(ranks []models.RankDefinition, metrics RankMetrics) *models.RankDefinition {
+	for i, rank := range ranks {
+		if metrics.BooksFinished >= rank.MinBooksFinished && metrics.PagesRead >= rank.MinPagesRead && metrics.LongestStreak >= rank.MinStreakDays {
+			return &ranks[i]
+		}
+	}
+
+	return nil
+}
+
+// EvaluateRank moves a user to the rank their reading earns, recording the
+// change in their rank history and optionally notifying them.
+func EvaluateRank(userID int, notify bool) error {
+	return evaluateRank(userID, RankDefinitions(), UserRankMetrics(userID), notify)
+}
+
+func evaluateRank(userID int, ranks []models.RankDefinition, metrics RankMetrics, notify bool) error {
+	var user models.User
+
+	db.GetDB().Where("id = ?", userID).First(&user)
+
+	if user.ID == 0 {
+		return ErrUserNotFound
+	}
+
+	name := ""
+	var rankID *int
+
+	if rank := MatchRank(ranks, metrics); rank != nil {
+		name = rank.Name
+		rankID = &rank.ID
+	}
+
+	if name == user.Rank {
+		return nil
+	}
+
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("rank", name).Error; err != nil {
+			return err
+		}
+
+		err := tx.Create(&models.RankHistory{
+			UserID:       userID,
+			RankID:       rankID,
+			Rank:         name,
+			PreviousRank: user.Rank,
+		}).Error
+
+		if err != nil || name == "" || !notify {
+			return err
+		}
+
+		return Notify(tx, userID, "rank_changed", fmt.Sprintf("Your rank is now %s", name))
+	})
+}
+
+// EvaluateAllRanks re-ranks every user, it runs after rank definitions change
+// and once when the default ranks are seeded.
+func EvaluateAllRanks(notify bool) {
+	ranks := RankDefinitions()
+
+	var ids []int
+
+	db.GetDB().Model(&models.User{}).Order("id").Pluck("id", &ids)
+
+	for _, id := range ids {
+		if err := evaluateRank(id, ranks, UserRankMetrics(id), notify); err != nil {
+			fmt.Println("Failed to evaluate rank of user", id, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+)
+
+var ErrStillReading = errors.New("finish or abandon the current read-through first")
+
+// ReadingTransitions lists the states a user book can move to from each state.
+var ReadingTransitions = map[string][]string{
+	models.StateWantToRead: {models.StateReading, models.StateFinished, models.StateAbandoned},
+	models.StateReading:    {models.StatePaused, models.StateFinished, models.StateAbandoned},
+	models.StatePaused:     {models.StateReading, models.StateFinished, models.StateAbandoned},
+	models.StateAbandoned:  {models.StateReading, models.StateWantToRead},
+	models.StateFinished:   {},
+}
+
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	if _, ok := ReadingTransitions[e.To]; !ok {
+		states := make([]string, 0, len(ReadingTransitions))
+
+		for state := range ReadingTransitions {
+			states = append(states, state)
+		}
+
+		sort.Strings(states)
+
+		return fmt.Sprintf("unknown state %q, use one of %s", e.To, strings.Join(states, ", "))
+	}
+
+	allowed := ReadingTransitions[e.From]
+
+	if len(allowed) == 0 {
+		return fmt.Sprintf("a %s book can't change state anymore, start a re-read instead", e.From)
+	}
+
+	return fmt.Sprintf("can't move from %s to %s, allowed: %s", e.From, e.To, strings.Join(allowed, ", "))
+}
+
+func ValidReadingState(state string) bool {
+	_, ok := ReadingTransitions[state]
+
+	return ok
+}
+
+func canTransition(from string, to string) bool {
+	for _, state := range ReadingTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionState moves a user book to another state and stamps the start and
+// finish dates. Staying in the same state is a no-op.
+func TransitionState(userBook *models.UserBooks, to string, at time.Time) error {
+	from := userBook.BookState
+
+	if from == "" {
+		from = models.StateWantToRead
+	}
+
+	if from == to {
+		userBook.BookState = to
+		return nil
+	}
+
+	if !ValidReadingState(to) || !canTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+
+	switch to {
+	case models.StateReading:
+		if userBook.StartedAt == nil {
+			userBook.StartedAt = &at
+		}
+	case models.StateFinished:
+		if userBook.StartedAt == nil {
+			userBook.StartedAt = &at
+		}
+
+		userBook.FinishedAt = &at
+	case models.StateWantToRead:
+		userBook.StartedAt = nil
+	}
+
+	userBook.BookState = to
+
+	return nil
+}
+
+// ApplyProgress keeps the state in line with the progress: any progress puts
+// a book back into reading, and reaching the end in its format finishes it.
+func ApplyProgress(userBook *models.UserBooks, book models.Book, at time.Time) {
+	if userBook.BookState == models.StateFinished {
+		return
+	}
+
+	if userBook.Progress > 0 || userBook.PagesRead > 0 {
+		TransitionState(userBook, models.StateReading, at)
+	}
+
+	if total := FormatTotal(userBook.Format, book); total > 0 && userBook.Progress >= total {
+		TransitionState(userBook, models.StateFinished, at)
+	}
+}
+
+// NewReadThrough opens a read-through from the user book's current progress
+// and makes it the one progress updates go to.
+func NewReadThrough(tx *gorm.DB, userBook *models.UserBooks) (models.ReadThrough, error) {
+	var count int64
+
+	tx.Model(&models.ReadThrough{}).Where("user_books_id = ?", userBook.UserBooksID).Count(&count)
+
+	readThrough := models.ReadThrough{
+		UserBooksID: userBook.UserBooksID,
+		UserID:      int(userBook.UserID),
+		BookID:      int(userBook.BookID),
+		Number:      int(count) + 1,
+		State:       userBook.BookState,
+		PagesRead:   userBook.PagesRead,
+		Format:      formatOrPaper(userBook.Format),
+		Progress:    userBook.Progress,
+		Percent:     userBook.Percent,
+		StartedAt:   userBook.StartedAt,
+		FinishedAt:  userBook.FinishedAt,
+	}
+
+	if err := tx.Create(&readThrough).Error; err != nil {
+		return readThrough, err
+	}
+
+	userBook.CurrentReadThroughID = &readThrough.ID
+
+	err := tx.Model(&models.UserBooks{}).Where("user_books_id = ?", userBook.UserBooksID).Update("current_read_through_id", readThrough.ID).Error
+
+	return readThrough, err
+}
+
+// SyncReadThrough copies the progress and state of a user book to its current
+// read-through, it is called after every change made through the user book.
+func SyncReadThrough(tx *gorm.DB, userBook *models.UserBooks) error {
+	if userBook.CurrentReadThroughID == nil {
+		_, err := NewReadThrough(tx, userBook)
+		return err
+	}
+
+	return tx.Model(&models.ReadThrough{}).Where("id = ?", *userBook.CurrentReadThroughID).Updates(map[string]interface{}{
+		"state":       userBook.BookState,
+		"pages_read":  userBook.PagesRead,
+		"format":      formatOrPaper(userBook.Format),
+		"progress":    userBook.Progress,
+		"percent":     userBook.Percent,
+		"started_at":  userBook.StartedAt,
+		"finished_at": userBook.FinishedAt,
+	}).Error
+}
+
+// StartReread begins a new read-through of a book the user finished or gave
+// up on, earlier read-throughs keep their dates, progress and rating.
+func StartReread(tx *gorm.DB, userBook *models.UserBooks, at time.Time) (models.ReadThrough, error) {
+	if userBook.BookState != models.StateFinished && userBook.BookState != models.StateAbandoned {
+		return models.ReadThrough{}, ErrStillReading
+	}
+
+	userBook.BookState = models.StateReading
+	userBook.PagesRead = 0
+	userBook.Progress = 0
+	userBook.Percent = 0
+	userBook.StartedAt = &at
+	userBook.FinishedAt = nil
+
+	err := tx.Model(&models.UserBooks{}).Where("user_books_id = ?", userBook.UserBooksID).Updates(map[string]interface{}{
+		"book_state":  userBook.BookState,
+		"pages_read":  userBook.PagesRead,
+		"format":      formatOrPaper(userBook.Format),
+		"progress":    userBook.Progress,
+		"percent":     userBook.Percent,
+		"started_at":  userBook.StartedAt,
+		"finished_at": userBook.FinishedAt,
+	}).Error
+
+	if err != nil {
+		return models.ReadThrough{}, err
+	}
+
+	return NewReadThrough(tx, userBook)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+// Users with fewer tracked books than this have too little history for
+// anything but content-based suggestions seeded from what they already have.
+const ColdStartThreshold = 5
+
+const recommendationSeeds = 10
+
+type Recommendations struct {
+	Strategy string       `json:"strategy"`
+	Books    []ScoredBook `json:"books"`
+}
+
+func RecommendForUser(userID int, limit int) Recommendations {
+	var userBooks []models.UserBooks
+
+	db.GetDB().Where("user_id = ?", userID).Order("user_books_id desc").Find(&userBooks)
+
+	owned := make(map[int]bool, len(userBooks))
+	seeds := make([]int, 0, recommendationSeeds)
+
+	for _, userBook := range userBooks {
+		owned[int(userBook.BookID)] = true
+
+		if len(seeds) < recommendationSeeds {
+			seeds = append(seeds, int(userBook.BookID))
+		}
+	}
+
+	strategy := "content"
+
+	if len(userBooks) < ColdStartThreshold {
+		strategy = "cold-start"
+	}
+
+	books := SimilarToBooks(seeds, owned, limit)
+
+	if len(books) < limit {
+		for _, book := range books {
+			owned[book.Book.ID] = true
+		}
+
+		books = append(books, popularBooks(owned, limit-len(books))...)
+	}
+
+	return Recommendations{
+		Strategy: strategy,
+		Books:    books,
+	}
+}
+
+func popularBooks(exclude map[int]bool, limit int) []ScoredBook {
+	type popularity struct {
+		BookID  int
+		Readers int64
+	}
+
+	var rows []popularity
+
+	db.GetDB().Model(&models.UserBooks{}).
+		Select("book_id, count(*) as readers").
+		Group("book_id").
+		Order("readers desc, book_id").
+		Scan(&rows)
+
+	ids := make([]int, 0, limit)
+
+	for _, row := range rows {
+		if len(ids) == limit {
+			break
+		}
+
+		if !exclude[row.BookID] {
+			ids = append(ids, row.BookID)
+			exclude[row.BookID] = true
+		}
+	}
+
+	if len(ids) < limit {
+		var fallback []models.Book
+
+		db.GetDB().Order("id desc").Limit(limit + len(exclude)).Find(&fallback)
+
+		for _, book := range fallback {
+			if len(ids) == limit {
+				break
+			}
+
+			if !exclude[book.ID] {
+				ids = append(ids, book.ID)
+				exclude[book.ID] = true
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return []ScoredBook{}
+	}
+
+	var books []models.Book
+
+	db.GetDB().Where("id IN ?", ids).Find(&books)
+
+	byID := make(map[int]models.Book, len(books))
+
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	result := make([]ScoredBook, 0, len(ids))
+
+	for _, id := range ids {
+		if book, ok := byID[id]; ok {
+			result = append(result, ScoredBook{Book: book})
+		}
+	}
+
+	return result
+}
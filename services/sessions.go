@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/catalinfl/readit-api/models"
+	"gorm.io/gorm"
+)
+
+var ErrSessionInFuture = errors.New("session can't be in the future")
+
+// MaxSessionMinutes caps the optional duration of a single session (a day).
+const MaxSessionMinutes = 24 * 60
+
+// SessionInput is a progress update, EndPage is the page equivalent of
+// Progress which is in the native unit of the user book's format.
+type SessionInput struct {
+	StartPage       *uint
+	EndPage         uint
+	Progress        float64
+	ReadAt          time.Time
+	DurationMinutes *uint
+}
+
+// RecordSession logs a progress update and moves the user book to the end
+// page (and native progress) of its latest session. Sessions may be logged
+// after the fact, so the start page defaults to where the previous session
+// (by read time) ended and the session after it is rebased onto this one.
+func RecordSession(tx *gorm.DB, userBook *models.UserBooks, input SessionInput) (models.ReadingSession, error) {
+	if input.ReadAt.IsZero() {
+		input.ReadAt = time.Now()
+	}
+
+	if input.ReadAt.After(time.Now().Add(time.Minute)) {
+		return models.ReadingSession{}, ErrSessionInFuture
+	}
+
+	session := models.ReadingSession{
+		UserBooksID:     userBook.UserBooksID,
+		ReadThroughID:   userBook.CurrentReadThroughID,
+		UserID:          int(userBook.UserID),
+		BookID:          int(userBook.BookID),
+		EndPage:         input.EndPage,
+		Format:          formatOrPaper(userBook.Format),
+		Progress:        input.Progress,
+		ReadAt:          input.ReadAt,
+		DurationMinutes: input.DurationMinutes,
+	}
+
+	var startPage uint
+
+	if input.StartPage != nil {
+		startPage = *input.StartPage
+	} else {
+		var previous models.ReadingSession
+
+		readThroughSessions(tx, userBook).Where("read_at <= ?", input.ReadAt).Order("read_at desc, id desc").First(&previous)
+
+		if previous.ID > 0 {
+			startPage = previous.EndPage
+		} else if !hasSessions(tx, userBook) {
+			// progress saved before sessions were logged
+			startPage = userBook.PagesRead
+		}
+	}
+
+	setStartPage(&session, startPage)
+
+	if err := tx.Create(&session).Error; err != nil {
+		return session, err
+	}
+
+	// the next session started where the previous one ended, its pages would
+	// otherwise be counted again
+	var next models.ReadingSession
+
+	readThroughSessions(tx, userBook).
+		Where("(read_at > ? OR (read_at = ? AND id > ?))", session.ReadAt, session.ReadAt, session.ID).
+		Order("read_at, id").
+		First(&next)
+
+	if next.ID > 0 {
+		setStartPage(&next, session.EndPage)
+
+		err := tx.Model(&next).Updates(map[string]interface{}{
+			"start_page": next.StartPage,
+			"pages":      next.Pages,
+		}).Error
+
+		if err != nil {
+			return session, err
+		}
+	}
+
+	var latest models.ReadingSession
+
+	readThroughSessions(tx, userBook).Order("read_at desc, id desc").First(&latest)
+
+	userBook.PagesRead = latest.EndPage
+
+	if latest.Format == formatOrPaper(userBook.Format) {
+		userBook.Progress = latest.Progress
+	}
+
+	return session, nil
+}
+
+// setStartPage moves the start of a session, going back never counts as
+// reading so Pages doesn't go below zero.
+func setStartPage(session *models.ReadingSession, startPage uint) {
+	session.StartPage = startPage
+	session.Pages = 0
+
+	if session.EndPage > startPage {
+		session.Pages = session.EndPage - startPage
+	}
+}
+
+// readThroughSessions scopes sessions to the current read-through, a re-read
+// starts again from the first page.
+func readThroughSessions(tx *gorm.DB, userBook *models.UserBooks) *gorm.DB {
+	query := tx.Model(&models.ReadingSession{}).Where("user_books_id = ?", userBook.UserBooksID)
+
+	if userBook.CurrentReadThroughID != nil {
+		query = query.Where("read_through_id = ?", *userBook.CurrentReadThroughID)
+	}
+
+	return query
+}
+
+func hasSessions(tx *gorm.DB, userBook *models.UserBooks) bool {
+	var count int64
+
+	readThroughSessions(tx, userBook).Count(&count)
+
+	return count > 0
+}
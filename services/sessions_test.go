@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/catalinfl/readit-api/models"
+)
+
+func totalPages(sessions []models.ReadingSession) uint {
+	var total uint
+
+	for _, session := range sessions {
+		total += session.Pages
+	}
+
+	return total
+}
+
+// TestBackdatedSessionKeepsTotal logs a session between two existing ones the
+// way RecordSession does: it starts where the previous one ended and the next
+// one is rebased onto it.
+func TestBackdatedSessionKeepsTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous int
+		endPage  uint
+	}{
+		{name: "between sessions", previous: 0, endPage: 80},
+		{name: "before every session", previous: -1, endPage: 30},
+		{name: "same page as the previous session", previous: 0, endPage: 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions := []models.ReadingSession{
+				{StartPage: 0, EndPage: 50, Pages: 50},
+				{StartPage: 50, EndPage: 120, Pages: 70},
+			}
+
+			before := totalPages(sessions)
+
+			session := models.ReadingSession{EndPage: test.endPage}
+
+			var startPage uint
+
+			if test.previous >= 0 {
+				startPage = sessions[test.previous].EndPage
+			}
+
+			setStartPage(&session, startPage)
+			setStartPage(&sessions[test.previous+1], session.EndPage)
+
+			if got := totalPages(append(sessions, session)); got != before {
+				t.Errorf("total pages = %d after a backdated session, want %d", got, before)
+			}
+		})
+	}
+}
+
+func TestSetStartPage(t *testing.T) {
+	session := models.ReadingSession{EndPage: 40, Pages: 40}
+
+	setStartPage(&session, 60)
+
+	if session.StartPage != 60 || session.Pages != 0 {
+		t.Errorf("setStartPage() = start %d, pages %d, want start 60, pages 0", session.StartPage, session.Pages)
+	}
+}
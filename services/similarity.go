@@ -0,0 +1,233 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+const similarityIndexTTL = 15 * time.Minute
+
+type ScoredBook struct {
+	Book  models.Book `json:"book"`
+	Score float64     `json:"score"`
+}
+
+type similarityIndex struct {
+	books   map[int]models.Book
+	vectors map[int]map[string]float64
+	builtAt time.Time
+}
+
+var (
+	similarityMu    sync.Mutex
+	similarityCache *similarityIndex
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "he": true, "her": true, "his": true, "in": true, "is": true,
+	"it": true, "its": true, "of": true, "on": true, "or": true, "she": true, "that": true, "the": true,
+	"their": true, "they": true, "this": true, "to": true, "was": true, "were": true, "who": true,
+	"will": true, "with": true, "de": true, "si": true, "la": true,
+}
+
+// InvalidateSimilarityIndex forces the next lookup to rebuild the index,
+// it should be called whenever book metadata changes.
+func InvalidateSimilarityIndex() {
+	similarityMu.Lock()
+	similarityCache = nil
+	similarityMu.Unlock()
+}
+
+func getSimilarityIndex() *similarityIndex {
+	similarityMu.Lock()
+	defer similarityMu.Unlock()
+
+	if similarityCache != nil && time.Since(similarityCache.builtAt) < similarityIndexTTL {
+		return similarityCache
+	}
+
+	var books []models.Book
+
+	db.GetDB().Find(&books)
+
+	similarityCache = buildSimilarityIndex(books)
+
+	return similarityCache
+}
+
+func buildSimilarityIndex(books []models.Book) *similarityIndex {
+	index := &similarityIndex{
+		books:   make(map[int]models.Book, len(books)),
+		vectors: make(map[int]map[string]float64, len(books)),
+		builtAt: time.Now(),
+	}
+
+	termFrequencies := make(map[int]map[string]float64, len(books))
+	documentFrequency := make(map[string]int)
+
+	for _, book := range books {
+		tf := bookTerms(book)
+
+		for term := range tf {
+			documentFrequency[term]++
+		}
+
+		index.books[book.ID] = book
+		termFrequencies[book.ID] = tf
+	}
+
+	total := float64(len(books))
+
+	for id, tf := range termFrequencies {
+		vector := make(map[string]float64, len(tf))
+
+		var norm float64
+
+		for term, freq := range tf {
+			idf := math.Log((1+total)/(1+float64(documentFrequency[term]))) + 1
+			weight := (1 + math.Log(freq)) * idf
+			vector[term] = weight
+			norm += weight * weight
+		}
+
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for term := range vector {
+				vector[term] /= norm
+			}
+		}
+
+		index.vectors[id] = vector
+	}
+
+	return index
+}
+
+// bookTerms weights the short, highly descriptive fields above the free text
+// description so that two books by the same author or in the same genre rank
+// close even when their blurbs differ.
+func bookTerms(book models.Book) map[string]float64 {
+	terms := make(map[string]float64)
+
+	add := func(text string, weight float64) {
+		for _, token := range tokenize(text) {
+			terms[token] += weight
+		}
+	}
+
+	add(book.Title, 2)
+	add(book.Author, 2)
+	add(book.Genre, 3)
+	add(book.Description, 1)
+
+	if author := strings.TrimSpace(strings.ToLower(book.Author)); author != "" {
+		terms["author:"+author] += 3
+	}
+
+	if genre := strings.TrimSpace(strings.ToLower(book.Genre)); genre != "" {
+		terms["genre:"+genre] += 3
+	}
+
+	return terms
+}
+
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || stopWords[field] {
+			continue
+		}
+
+		tokens = append(tokens, field)
+	}
+
+	return tokens
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	var dot float64
+
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+
+	return dot
+}
+
+// SimilarBooks returns the nearest neighbours of a book by metadata similarity.
+func SimilarBooks(bookID int, limit int) []ScoredBook {
+	return SimilarToBooks([]int{bookID}, nil, limit)
+}
+
+// SimilarToBooks ranks every book against the centroid of the seed books,
+// skipping the seeds themselves and any id in exclude.
+func SimilarToBooks(seedIDs []int, exclude map[int]bool, limit int) []ScoredBook {
+	index := getSimilarityIndex()
+
+	centroid := make(map[string]float64)
+	seeds := make(map[int]bool, len(seedIDs))
+
+	for _, id := range seedIDs {
+		vector, ok := index.vectors[id]
+
+		if !ok {
+			continue
+		}
+
+		seeds[id] = true
+
+		for term, weight := range vector {
+			centroid[term] += weight
+		}
+	}
+
+	if len(centroid) == 0 {
+		return []ScoredBook{}
+	}
+
+	results := make([]ScoredBook, 0)
+
+	for id, vector := range index.vectors {
+		if seeds[id] || exclude[id] {
+			continue
+		}
+
+		score := cosine(centroid, vector) / float64(len(seeds))
+
+		if score <= 0 {
+			continue
+		}
+
+		results = append(results, ScoredBook{Book: index.books[id], Score: math.Round(score*1000) / 1000})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Book.ID < results[j].Book.ID
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+const (
+	statsTTL = 30 * time.Minute
+	// only recent years are cached, older ones are rarely looked at
+	statsCachedYears = 5
+)
+
+type MonthStats struct {
+	Month int `json:"month"`
+	Books int `json:"books"`
+	Pages int `json:"pages"`
+}
+
+type CountStat struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type UserStats struct {
+	Year                int          `json:"year"`
+	Months              []MonthStats `json:"months"`
+	BooksFinished       int          `json:"books_finished"`
+	PagesRead           int          `json:"pages_read"`
+	Genres              []CountStat  `json:"genres"`
+	Languages           []CountStat  `json:"languages"`
+	TopAuthors          []CountStat  `json:"top_authors"`
+	Formats             []CountStat  `json:"formats"`
+	AverageBookLength   float64      `json:"average_book_length"`
+	AverageDaysToFinish float64      `json:"average_days_to_finish"`
+	RatingDistribution  []CountStat  `json:"rating_distribution"`
+	AverageRating       float64      `json:"average_rating"`
+	GeneratedAt         time.Time    `json:"generated_at"`
+}
+
+type statsKey struct {
+	userID int
+	year   int
+}
+
+var (
+	statsMu    sync.Mutex
+	statsCache = make(map[statsKey]UserStats)
+)
+
+// InvalidateUserStats drops every cached year of a user's statistics.
+func InvalidateUserStats(userID int) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	for key := range statsCache {
+		if key.userID == userID {
+			delete(statsCache, key)
+		}
+	}
+}
+
+// Stats returns the reading statistics of a user, the monthly breakdown is
+// for the given year while distributions cover the whole shelf.
+func Stats(userID int, year int) UserStats {
+	if current := time.Now().Year(); year > current || year <= current-statsCachedYears {
+		return computeStats(userID, year)
+	}
+
+	key := statsKey{userID: userID, year: year}
+
+	statsMu.Lock()
+	cached, ok := statsCache[key]
+	statsMu.Unlock()
+
+	if ok && time.Since(cached.GeneratedAt) < statsTTL {
+		return cached
+	}
+
+	stats := computeStats(userID, year)
+
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	// expired entries are dropped here so users who stop looking don't linger
+	for cachedKey, cachedStats := range statsCache {
+		if time.Since(cachedStats.GeneratedAt) >= statsTTL {
+			delete(statsCache, cachedKey)
+		}
+	}
+
+	statsCache[key] = stats
+
+	return stats
+}
+
+func computeStats(userID int, year int) UserStats {
+	loc := UserLocation(userID)
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	stats := UserStats{
+		Year:        year,
+		Months:      make([]MonthStats, 12),
+		GeneratedAt: time.Now(),
+	}
+
+	for i := range stats.Months {
+		stats.Months[i].Month = i + 1
+	}
+
+	var finished []struct {
+		Month int
+		Count int
+	}
+
+	db.GetDB().Model(&models.ReadThrough{}).
+		Select("extract(month from finished_at AT TIME ZONE ?)::int AS month, count(*) AS count", loc.String()).
+		Where("user_id = ? AND state = ? AND finished_at >= ? AND finished_at < ?", userID, models.StateFinished, start, end).
+		Group("month").
+		Scan(&finished)
+
+	for _, month := range finished {
+		stats.Months[month.Month-1].Books = month.Count
+		stats.BooksFinished += month.Count
+	}
+
+	var pages []struct {
+		Month int
+		Pages int
+	}
+
+	db.GetDB().Model(&models.ReadingSession{}).
+		Select("extract(month from read_at AT TIME ZONE ?)::int AS month, coalesce(sum(pages), 0) AS pages", loc.String()).
+		Where("user_id = ? AND read_at >= ? AND read_at < ?", userID, start, end).
+		Group("month").
+		Scan(&pages)
+
+	for _, month := range pages {
+		stats.Months[month.Month-1].Pages = month.Pages
+		stats.PagesRead += month.Pages
+	}
+
+	shelf := func(column string, limit int) []CountStat {
+		counts := []CountStat{}
+
+		query := db.GetDB().Table("user_books").
+			Select("books."+column+" AS name, count(*) AS count").
+			Joins("JOIN books ON books.id = user_books.book_id").
+			Where("user_books.user_id = ? AND books."+column+" <> ''", userID).
+			Group("books." + column).
+			Order("count desc, name")
+
+		if limit > 0 {
+			query = query.Limit(limit)
+		}
+
+		query.Scan(&counts)
+
+		return counts
+	}
+
+	stats.Genres = shelf("genre", 0)
+	stats.Languages = shelf("language", 0)
+	stats.TopAuthors = shelf("author", 10)
+
+	stats.Formats = []CountStat{}
+
+	db.GetDB().Model(&models.UserBooks{}).
+		Select("format AS name, count(*) AS count").
+		Where("user_id = ?", userID).
+		Group("format").
+		Order("count desc, name").
+		Scan(&stats.Formats)
+
+	db.GetDB().Table("read_throughs").
+		Select("coalesce(avg(books.pages), 0)").
+		Joins("JOIN books ON books.id = read_throughs.book_id").
+		Where("read_throughs.user_id = ? AND read_throughs.state = ? AND books.pages > 0", userID, models.StateFinished).
+		Scan(&stats.AverageBookLength)
+
+	db.GetDB().Model(&models.ReadThrough{}).
+		Select("coalesce(avg(extract(epoch from finished_at - started_at) / 86400), 0)").
+		Where("user_id = ? AND state = ? AND started_at IS NOT NULL AND finished_at >= started_at", userID, models.StateFinished).
+		Scan(&stats.AverageDaysToFinish)
+
+	stats.RatingDistribution = []CountStat{}
+
+	db.GetDB().Model(&models.Review{}).
+		Select("to_char(rating, 'FM0.0') AS name, count(*) AS count").
+		Where("user_id = ?", userID).
+		Group("name").
+		Order("name").
+		Scan(&stats.RatingDistribution)
+
+	db.GetDB().Model(&models.Review{}).
+		Select("coalesce(avg(rating), 0)").
+		Where("user_id = ?", userID).
+		Scan(&stats.AverageRating)
+
+	stats.AverageBookLength = roundTo(stats.AverageBookLength, 1)
+	stats.AverageDaysToFinish = roundTo(stats.AverageDaysToFinish, 1)
+	stats.AverageRating = roundTo(stats.AverageRating, 2)
+
+	return stats
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"time"
+	// keeps user timezones working on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+const dayLayout = "2006-01-02"
+
+type DayActivity struct {
+	Date     string `json:"date"`
+	Pages    int    `json:"pages"`
+	Sessions int    `json:"sessions"`
+	Minutes  int    `json:"minutes"`
+}
+
+type Streak struct {
+	Current    int    `json:"current"`
+	Longest    int    `json:"longest"`
+	LastActive string `json:"last_active"`
+	ReadToday  bool   `json:"read_today"`
+	Timezone   string `json:"timezone"`
+}
+
+// UserLocation resolves the timezone a user configured, UTC when unset.
+func UserLocation(userID int) *time.Location {
+	var user models.User
+
+	db.GetDB().Select("id, timezone").Where("id = ?", userID).First(&user)
+
+	if user.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// DailyActivity sums the reading sessions of a user per local day between
+// from (inclusive) and to (exclusive), days without reading are left out.
+// Only sessions that moved forward or have a duration count as reading, as do
+// ebook and audiobook sessions with progress, which may round to no pages.
+func DailyActivity(userID int, loc *time.Location, from time.Time, to time.Time) []DayActivity {
+	var days []DayActivity
+
+	localDay := "to_char(read_at AT TIME ZONE ?, 'YYYY-MM-DD')"
+
+	query := db.GetDB().Model(&models.ReadingSession{}).
+		Select(localDay+" AS date, coalesce(sum(pages), 0) AS pages, count(*) AS sessions, coalesce(sum(duration_minutes), 0) AS minutes", loc.String()).
+		Where("user_id = ? AND (pages > 0 OR duration_minutes > 0 OR (format <> ? AND progress > 0))", userID, models.FormatPaper)
+
+	if !from.IsZero() {
+		query = query.Where("read_at >= ?", from)
+	}
+
+	if !to.IsZero() {
+		query = query.Where("read_at < ?", to)
+	}
+
+	query.Group("date").Order("date").Scan(&days)
+
+	return days
+}
+
+// UserStreak counts consecutive reading days in the user's timezone. Today
+// not being read yet doesn't break the current streak until the day is over.
+func UserStreak(userID int) Streak {
+	loc := UserLocation(userID)
+	days := DailyActivity(userID, loc, time.Time{}, time.Time{})
+
+	streak := Streak{Timezone: loc.String()}
+
+	if len(days) == 0 {
+		return streak
+	}
+
+	var previous time.Time
+
+	run := 0
+
+	for _, day := range days {
+		date, err := time.ParseInLocation(dayLayout, day.Date, loc)
+
+		if err != nil {
+			continue
+		}
+
+		if !previous.IsZero() && previous.AddDate(0, 0, 1).Equal(date) {
+			run++
+		} else {
+			run = 1
+		}
+
+		if run > streak.Longest {
+			streak.Longest = run
+		}
+
+		previous = date
+	}
+
+	now := time.Now().In(loc)
+	today := now.Format(dayLayout)
+	yesterday := now.AddDate(0, 0, -1).Format(dayLayout)
+
+	streak.LastActive = days[len(days)-1].Date
+	streak.ReadToday = streak.LastActive == today
+
+	if streak.LastActive == today || streak.LastActive == yesterday {
+		streak.Current = run
+	}
+
+	return streak
+}
+
+// Heatmap returns every day of a year in the user's timezone with the pages
+// read on it, including the days without any reading.
+func Heatmap(userID int, year int) []DayActivity {
+	loc := UserLocation(userID)
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	byDate := make(map[string]DayActivity)
+
+	for _, day := range DailyActivity(userID, loc, start, end) {
+		byDate[day.Date] = day
+	}
+
+	days := make([]DayActivity, 0, 366)
+
+	for date := start; date.Before(end); date = date.AddDate(0, 0, 1) {
+		key := date.Format(dayLayout)
+
+		if day, ok := byDate[key]; ok {
+			days = append(days, day)
+		} else {
+			days = append(days, DayActivity{Date: key})
+		}
+	}
+
+	return days
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+var TrendingMetrics = []string{"added", "finished", "progressed"}
+
+var TrendingWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+type TrendingBook struct {
+	Book  models.Book `json:"book"`
+	Score int64       `json:"score"`
+}
+
+type trendingSnapshot struct {
+	lists       map[string][]TrendingBook
+	refreshedAt time.Time
+}
+
+var (
+	trendingMu    sync.RWMutex
+	trendingCache *trendingSnapshot
+)
+
+func trendingKey(metric string, window string) string {
+	return metric + ":" + window
+}
+
+// RefreshTrending recomputes every metric and window combination and swaps
+// the cached snapshot in one go, so readers never see a partial refresh.
+func RefreshTrending() {
+	snapshot := &trendingSnapshot{
+		lists:       make(map[string][]TrendingBook),
+		refreshedAt: time.Now(),
+	}
+
+	for _, metric := range TrendingMetrics {
+		for window, duration := range TrendingWindows {
+			var since time.Time
+
+			if duration > 0 {
+				since = snapshot.refreshedAt.Add(-duration)
+			}
+
+			snapshot.lists[trendingKey(metric, window)] = computeTrending(metric, since)
+		}
+	}
+
+	trendingMu.Lock()
+	trendingCache = snapshot
+	trendingMu.Unlock()
+}
+
+func computeTrending(metric string, since time.Time) []TrendingBook {
+	type score struct {
+		BookID int
+		Score  int64
+	}
+
+	var scores []score
+
+	query := db.GetDB().Model(&models.UserBooks{}).Group("book_id").Order("score desc, book_id")
+
+	switch metric {
+	case "added":
+		query = query.Select("book_id, count(*) as score")
+		if !since.IsZero() {
+			query = query.Where("created_at >= ?", since)
+		}
+	case "finished":
+		// every completed read counts, re-reads included
+		query = db.GetDB().Model(&models.ReadThrough{}).
+			Select("book_id, count(*) as score").
+			Where("state = ? AND finished_at IS NOT NULL", models.StateFinished).
+			Group("book_id").
+			Order("score desc, book_id")
+		if !since.IsZero() {
+			query = query.Where("finished_at >= ?", since)
+		}
+	case "progressed":
+		query = db.GetDB().Model(&models.ReadingSession{}).
+			Select("book_id, coalesce(sum(pages), 0) as score").
+			Group("book_id").
+			Order("score desc, book_id")
+		if !since.IsZero() {
+			query = query.Where("read_at >= ?", since)
+		}
+	}
+
+	query.Scan(&scores)
+
+	if len(scores) == 0 {
+		return []TrendingBook{}
+	}
+
+	ids := make([]int, 0, len(scores))
+
+	for _, s := range scores {
+		ids = append(ids, s.BookID)
+	}
+
+	var books []models.Book
+
+	db.GetDB().Where("id IN ?", ids).Find(&books)
+
+	byID := make(map[int]models.Book, len(books))
+
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	result := make([]TrendingBook, 0, len(scores))
+
+	for _, s := range scores {
+		if book, ok := byID[s.BookID]; ok && s.Score > 0 {
+			result = append(result, TrendingBook{Book: book, Score: s.Score})
+		}
+	}
+
+	return result
+}
+
+// Trending returns the cached ranking for a metric and window, optionally
+// narrowed down to a genre and/or language (case insensitive).
+func Trending(metric string, window string, genre string, language string, limit int) ([]TrendingBook, time.Time) {
+	trendingMu.RLock()
+	snapshot := trendingCache
+	trendingMu.RUnlock()
+
+	if snapshot == nil {
+		RefreshTrending()
+
+		trendingMu.RLock()
+		snapshot = trendingCache
+		trendingMu.RUnlock()
+	}
+
+	result := make([]TrendingBook, 0, limit)
+
+	for _, entry := range snapshot.lists[trendingKey(metric, window)] {
+		if len(result) == limit {
+			break
+		}
+
+		if genre != "" && !strings.EqualFold(entry.Book.Genre, genre) {
+			continue
+		}
+
+		if language != "" && !strings.EqualFold(entry.Book.Language, language) {
+			continue
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, snapshot.refreshedAt
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"time"
+
+	"github.com/catalinfl/readit-api/db"
+	"github.com/catalinfl/readit-api/models"
+)
+
+type ReviewBook struct {
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	Author     string    `json:"author"`
+	Genre      string    `json:"genre"`
+	Pages      int       `json:"pages"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type YearReview struct {
+	Year          int          `json:"year"`
+	UserName      string       `json:"user_name"`
+	TotalBooks    int          `json:"total_books"`
+	TotalPages    int          `json:"total_pages"`
+	LongestBook   *ReviewBook  `json:"longest_book"`
+	ShortestBook  *ReviewBook  `json:"shortest_book"`
+	TopGenre      string       `json:"top_genre"`
+	TopGenreBooks int          `json:"top_genre_books"`
+	FirstBook     *ReviewBook  `json:"first_book"`
+	LastBook      *ReviewBook  `json:"last_book"`
+	Months        []MonthStats `json:"months"`
+}
+
+// YearInReview recaps the books a user finished during a year (re-reads
+// included) on top of the monthly numbers of their statistics.
+func YearInReview(userID int, year int) YearReview {
+	var user models.User
+
+	db.GetDB().Select("id, name").Where("id = ?", userID).First(&user)
+
+	stats := Stats(userID, year)
+
+	review := YearReview{
+		Year:       year,
+		UserName:   user.Name,
+		TotalBooks: stats.BooksFinished,
+		TotalPages: stats.PagesRead,
+		Months:     stats.Months,
+	}
+
+	loc := UserLocation(userID)
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	var books []ReviewBook
+
+	db.GetDB().Table("read_throughs").
+		Select("books.id, books.title, books.author, books.genre, books.pages, read_throughs.finished_at").
+		Joins("JOIN books ON books.id = read_throughs.book_id").
+		Where("read_throughs.user_id = ? AND read_throughs.state = ? AND read_throughs.finished_at >= ? AND read_throughs.finished_at < ?", userID, models.StateFinished, start, end).
+		Order("read_throughs.finished_at").
+		Scan(&books)
+
+	if len(books) == 0 {
+		return review
+	}
+
+	review.FirstBook = &books[0]
+	review.LastBook = &books[len(books)-1]
+
+	genres := make(map[string]int)
+
+	for i := range books {
+		book := &books[i]
+
+		if book.Genre != "" {
+			genres[book.Genre]++
+
+			if count := genres[book.Genre]; count > review.TopGenreBooks || (count == review.TopGenreBooks && book.Genre < review.TopGenre) {
+				review.TopGenre = book.Genre
+				review.TopGenreBooks = count
+			}
+		}
+
+		if book.Pages == 0 {
+			continue
+		}
+
+		if review.LongestBook == nil || book.Pages > review.LongestBook.Pages {
+			review.LongestBook = book
+		}
+
+		if review.ShortestBook == nil || book.Pages < review.ShortestBook.Pages {
+			review.ShortestBook = book
+		}
+	}
+
+	return review
+}
@@ -0,0 +1,403 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+)
+
+const (
+	eanModules   = 95
+	eanRuns      = 59
+	scanlines    = 40
+	maxScanWidth = 1600
+	// neighbouring lines averaged into each scanline to smooth sensor noise
+	bandRadius = 2
+	// images are checked against this before decoding, a small compressed
+	// file can declare dimensions that take gigabytes once decoded
+	maxImagePixels = 4000 * 4000
+)
+
+var (
+	ErrNoBarcode     = errors.New("no EAN-13 barcode found")
+	ErrImageTooLarge = errors.New("image is too large")
+)
+
+// Run widths of the odd parity (L) digit codes, in modules. Right hand (R)
+// codes share the same widths with colors swapped, even parity (G) codes are
+// the L widths reversed.
+var eanDigitWidths = [10][4]float64{
+	{3, 2, 1, 1},
+	{2, 2, 2, 1},
+	{2, 1, 2, 2},
+	{1, 4, 1, 1},
+	{1, 1, 3, 2},
+	{1, 2, 3, 1},
+	{1, 1, 1, 4},
+	{1, 3, 1, 2},
+	{1, 2, 1, 3},
+	{3, 1, 1, 2},
+}
+
+// parity of the six left digits (true = G) encodes the implicit first digit
+var eanFirstDigit = map[[6]bool]int{
+	{false, false, false, false, false, false}: 0,
+	{false, false, true, false, true, true}:    1,
+	{false, false, true, true, false, true}:    2,
+	{false, false, true, true, true, false}:    3,
+	{false, true, false, false, true, true}:    4,
+	{false, true, true, false, false, true}:    5,
+	{false, true, true, true, false, false}:    6,
+	{false, true, false, true, false, true}:    7,
+	{false, true, false, true, true, false}:    8,
+	{false, true, true, false, true, false}:    9,
+}
+
+// DecodeEAN13 looks for an EAN-13 barcode (the ISBN barcode on book covers)
+// in a photo. Horizontal and vertical scanlines are read in both directions
+// and the value read most often wins.
+func DecodeEAN13(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return "", err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+
+	if err != nil {
+		return "", err
+	}
+
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return "", ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return "", err
+	}
+
+	luma := luminance(img)
+	votes := make(map[string]int)
+
+	height := len(luma)
+
+	if height == 0 {
+		return "", ErrNoBarcode
+	}
+
+	width := len(luma[0])
+
+	for i := 1; i <= scanlines; i++ {
+		y := height * i / (scanlines + 1)
+		row := make([]float64, width)
+
+		for dy := -bandRadius; dy <= bandRadius; dy++ {
+			line := luma[min(max(y+dy, 0), height-1)]
+
+			for x := range row {
+				row[x] += line[x] / (2*bandRadius + 1)
+			}
+		}
+
+		scanEAN(row, votes)
+
+		x := width * i / (scanlines + 1)
+		column := make([]float64, height)
+
+		for cy := range luma {
+			for dx := -bandRadius; dx <= bandRadius; dx++ {
+				column[cy] += luma[cy][min(max(x+dx, 0), width-1)] / (2*bandRadius + 1)
+			}
+		}
+
+		scanEAN(column, votes)
+	}
+
+	best := ""
+
+	for code, count := range votes {
+		if count > votes[best] || (count == votes[best] && code < best) {
+			best = code
+		}
+	}
+
+	if best == "" {
+		return "", ErrNoBarcode
+	}
+
+	return best, nil
+}
+
+// luminance converts the image to a grid of gray values, large photos are
+// sampled down first since barcodes stay readable well below camera size.
+func luminance(img image.Image) [][]float64 {
+	bounds := img.Bounds()
+
+	step := 1
+
+	if longest := max(bounds.Dx(), bounds.Dy()); longest > maxScanWidth {
+		step = int(math.Ceil(float64(longest) / maxScanWidth))
+	}
+
+	rows := make([][]float64, 0, bounds.Dy()/step+1)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		row := make([]float64, 0, bounds.Dx()/step+1)
+
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			row = append(row, (0.299*float64(r)+0.587*float64(g)+0.114*float64(b))/257)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// binarize thresholds a scanline against the mean of its neighbourhood so
+// uneven lighting across the cover doesn't swallow bars.
+func binarize(line []float64) []bool {
+	n := len(line)
+	window := max(n/12, 8)
+
+	prefix := make([]float64, n+1)
+
+	for i, v := range line {
+		prefix[i+1] = prefix[i] + v
+	}
+
+	low, high := 255.0, 0.0
+
+	for _, v := range line {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+
+	dark := make([]bool, n)
+
+	for i, v := range line {
+		from := max(i-window, 0)
+		to := min(i+window, n)
+		mean := (prefix[to] - prefix[from]) / float64(to-from)
+
+		dark[i] = v < mean-2 && v < (low+high)/2+(high-low)/4
+	}
+
+	return dark
+}
+
+type eanRun struct {
+	dark  bool
+	width float64
+}
+
+func runLengths(dark []bool) []eanRun {
+	var runs []eanRun
+
+	for _, d := range dark {
+		if len(runs) > 0 && runs[len(runs)-1].dark == d {
+			runs[len(runs)-1].width++
+			continue
+		}
+
+		runs = append(runs, eanRun{dark: d, width: 1})
+	}
+
+	return runs
+}
+
+func scanEAN(line []float64, votes map[string]int) {
+	runs := runLengths(binarize(line))
+
+	reversed := make([]eanRun, len(runs))
+
+	for i, run := range runs {
+		reversed[len(runs)-1-i] = run
+	}
+
+	for _, candidate := range [][]eanRun{runs, reversed} {
+		for start := 1; start+eanRuns <= len(candidate); start++ {
+			if !candidate[start].dark {
+				continue
+			}
+
+			if code, ok := decodeEANAt(candidate, start); ok {
+				votes[code]++
+				break
+			}
+		}
+	}
+}
+
+func decodeEANAt(runs []eanRun, start int) (string, bool) {
+	var total float64
+
+	for _, run := range runs[start : start+eanRuns] {
+		total += run.width
+	}
+
+	module := total / eanModules
+
+	// the quiet zone before the start guard must be clearly wider than a bar
+	if runs[start-1].width < module*3 {
+		return "", false
+	}
+
+	if !guardMatches(runs[start:start+3], module) || !guardMatches(runs[start+27:start+32], module) || !guardMatches(runs[start+56:start+59], module) {
+		return "", false
+	}
+
+	digits := make([]int, 13)
+
+	var parity [6]bool
+
+	for i := 0; i < 6; i++ {
+		digit, even, ok := decodeDigit(runs[start+3+i*4:start+7+i*4], true)
+
+		if !ok {
+			return "", false
+		}
+
+		digits[i+1] = digit
+		parity[i] = even
+	}
+
+	for i := 0; i < 6; i++ {
+		digit, _, ok := decodeDigit(runs[start+32+i*4:start+36+i*4], false)
+
+		if !ok {
+			return "", false
+		}
+
+		digits[i+7] = digit
+	}
+
+	first, ok := eanFirstDigit[parity]
+
+	if !ok {
+		return "", false
+	}
+
+	digits[0] = first
+
+	code := make([]byte, 13)
+
+	for i, d := range digits {
+		code[i] = byte('0' + d)
+	}
+
+	if !ValidEAN13(string(code)) {
+		return "", false
+	}
+
+	return string(code), true
+}
+
+func guardMatches(runs []eanRun, module float64) bool {
+	for _, run := range runs {
+		if run.width < module*0.4 || run.width > module*1.9 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeDigit(runs []eanRun, left bool) (int, bool, bool) {
+	var sum float64
+
+	for _, run := range runs {
+		sum += run.width
+	}
+
+	if sum == 0 {
+		return 0, false, false
+	}
+
+	scale := 7 / sum
+
+	best, bestEven, bestError := -1, false, math.MaxFloat64
+
+	for digit, widths := range eanDigitWidths {
+		parities := []bool{false}
+
+		if left {
+			parities = []bool{false, true}
+		}
+
+		for _, even := range parities {
+			var errorSum float64
+
+			for i, run := range runs {
+				expected := widths[i]
+
+				if even {
+					expected = widths[3-i]
+				}
+
+				errorSum += math.Abs(run.width*scale - expected)
+			}
+
+			if errorSum < bestError {
+				best, bestEven, bestError = digit, even, errorSum
+			}
+		}
+	}
+
+	return best, bestEven, best >= 0 && bestError < 2.2
+}
+
+func ValidEAN13(code string) bool {
+	if len(code) != 13 {
+		return false
+	}
+
+	sum := 0
+
+	for i, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		digit := int(c - '0')
+
+		if i%2 == 1 {
+			digit *= 3
+		}
+
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+// ISBN10 converts a Bookland EAN (978 prefix) to the older ISBN-10 form, it
+// returns an empty string for 979 numbers which have no ISBN-10 equivalent.
+func ISBN10(isbn13 string) string {
+	if len(isbn13) != 13 || isbn13[:3] != "978" {
+		return ""
+	}
+
+	body := isbn13[3:12]
+	sum := 0
+
+	for i, c := range body {
+		sum += int(c-'0') * (10 - i)
+	}
+
+	check := (11 - sum%11) % 11
+
+	if check == 10 {
+		return body + "X"
+	}
+
+	return body + string(rune('0'+check))
+}
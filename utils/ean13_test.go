@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/ean"
+)
+
+// Standard EAN-13 digit codes, written out independently of eanDigitWidths so
+// the tests don't share a table with the decoder.
+var (
+	testLCodes  = []string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	testParity  = []string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+	testModule  = 4
+	testMargin  = 60
+	testHeight  = 200
+	testISBN    = "9780306406157"
+	testBadISBN = "9780306406158"
+)
+
+// eanBars encodes 13 digits as 95 modules without checking the check digit.
+func eanBars(code string) string {
+	var bars strings.Builder
+
+	bars.WriteString("101")
+
+	for i := 1; i <= 6; i++ {
+		l := testLCodes[code[i]-'0']
+
+		if testParity[code[0]-'0'][i-1] == 'G' {
+			// G codes are R codes reversed, R codes are L codes inverted
+			inverted := []byte(invertBars(l))
+
+			for a, b := 0, len(inverted)-1; a < b; a, b = a+1, b-1 {
+				inverted[a], inverted[b] = inverted[b], inverted[a]
+			}
+
+			l = string(inverted)
+		}
+
+		bars.WriteString(l)
+	}
+
+	bars.WriteString("01010")
+
+	for i := 7; i <= 12; i++ {
+		bars.WriteString(invertBars(testLCodes[code[i]-'0']))
+	}
+
+	bars.WriteString("101")
+
+	return bars.String()
+}
+
+func invertBars(bars string) string {
+	return strings.NewReplacer("0", "1", "1", "0").Replace(bars)
+}
+
+func barsImage(bars string) image.Image {
+	img := whiteImage(len(bars)*testModule+2*testMargin, testHeight+2*testMargin)
+
+	for i, bar := range bars {
+		if bar == '1' {
+			draw.Draw(img, image.Rect(testMargin+i*testModule, testMargin, testMargin+(i+1)*testModule, testMargin+testHeight), image.Black, image.Point{}, draw.Src)
+		}
+	}
+
+	return img
+}
+
+func generatedImage(t *testing.T, code string) image.Image {
+	encoded, err := ean.Encode(code)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scaled, err := barcode.Scale(encoded, eanModules*testModule, testHeight)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := whiteImage(scaled.Bounds().Dx()+2*testMargin, scaled.Bounds().Dy()+2*testMargin)
+	draw.Draw(img, scaled.Bounds().Add(image.Pt(testMargin, testMargin)), scaled, image.Point{}, draw.Src)
+
+	return img
+}
+
+func whiteImage(width int, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	return img
+}
+
+func rotate(img image.Image) image.Image {
+	bounds := img.Bounds()
+	rotated := image.NewRGBA(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			rotated.Set(bounds.Dy()-1-y, x, img.At(x, y))
+		}
+	}
+
+	return rotated
+}
+
+func TestDecodeEAN13(t *testing.T) {
+	tests := []struct {
+		name string
+		img  func(t *testing.T) image.Image
+		want string
+		err  error
+	}{
+		{name: "generated barcode", img: func(t *testing.T) image.Image { return generatedImage(t, testISBN) }, want: testISBN},
+		{name: "rotated barcode", img: func(t *testing.T) image.Image { return rotate(generatedImage(t, testISBN)) }, want: testISBN},
+		{name: "upside down barcode", img: func(t *testing.T) image.Image { return rotate(rotate(generatedImage(t, testISBN))) }, want: testISBN},
+		{name: "hand drawn bars", img: func(t *testing.T) image.Image { return barsImage(eanBars(testISBN)) }, want: testISBN},
+		{name: "bad check digit", img: func(t *testing.T) image.Image { return barsImage(eanBars(testBadISBN)) }, err: ErrNoBarcode},
+		{name: "blank image", img: func(t *testing.T) image.Image { return whiteImage(600, 400) }, err: ErrNoBarcode},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			if err := png.Encode(&buffer, test.img(t)); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := DecodeEAN13(&buffer)
+
+			if err != test.err {
+				t.Fatalf("DecodeEAN13() error = %v, want %v", err, test.err)
+			}
+
+			if got != test.want {
+				t.Errorf("DecodeEAN13() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEAN13NotAnImage(t *testing.T) {
+	if _, err := DecodeEAN13(strings.NewReader("not an image")); err == nil {
+		t.Error("DecodeEAN13() decoded text as an image")
+	}
+}
+
+func TestDecodeEAN13TooLarge(t *testing.T) {
+	var buffer bytes.Buffer
+
+	// a blank PNG compresses to a few kilobytes whatever its dimensions
+	if err := png.Encode(&buffer, image.NewGray(image.Rect(0, 0, 5000, 4000))); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecodeEAN13(&buffer); err != ErrImageTooLarge {
+		t.Errorf("DecodeEAN13() error = %v, want %v", err, ErrImageTooLarge)
+	}
+}
+
+func TestValidEAN13(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{code: testISBN, want: true},
+		{code: "9791032305690", want: true},
+		{code: testBadISBN, want: false},
+		{code: "978030640615", want: false},
+		{code: "97803064061a7", want: false},
+	}
+
+	for _, test := range tests {
+		if got := ValidEAN13(test.code); got != test.want {
+			t.Errorf("ValidEAN13(%q) = %v, want %v", test.code, got, test.want)
+		}
+	}
+}
+
+func TestISBN10(t *testing.T) {
+	tests := []struct {
+		isbn13 string
+		want   string
+	}{
+		{isbn13: testISBN, want: "0306406152"},
+		{isbn13: "9780804429573", want: "080442957X"},
+		{isbn13: "9791032305690", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := ISBN10(test.isbn13); got != test.want {
+			t.Errorf("ISBN10(%q) = %q, want %q", test.isbn13, got, test.want)
+		}
+	}
+}
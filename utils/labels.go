@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+const (
+	linearQuietZone  = 10
+	linearBarHeight  = 50
+	matrixQuietZone  = 4
+	pngLinearModule  = 2
+	pngMatrixModule  = 8
+	svgLinearModule  = 2
+	svgMatrixModule  = 8
+	maxSymbolContent = 512
+)
+
+// Symbol is an encoded barcode reduced to its dark/light modules, so the same
+// data can be drawn as PNG, SVG or straight into a PDF label sheet.
+type Symbol struct {
+	Modules [][]bool
+	Matrix  bool
+	Text    string
+}
+
+func symbolFromBarcode(bc barcode.Barcode, matrix bool) Symbol {
+	bounds := bc.Bounds()
+
+	modules := make([][]bool, bounds.Dy())
+
+	for y := 0; y < bounds.Dy(); y++ {
+		modules[y] = make([]bool, bounds.Dx())
+
+		for x := 0; x < bounds.Dx(); x++ {
+			gray := color.GrayModel.Convert(bc.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			modules[y][x] = gray.Y < 128
+		}
+	}
+
+	return Symbol{Modules: modules, Matrix: matrix, Text: bc.Content()}
+}
+
+func Code128(content string) (Symbol, error) {
+	if content == "" || len(content) > maxSymbolContent {
+		return Symbol{}, errors.New("invalid barcode content")
+	}
+
+	bc, err := code128.Encode(content)
+
+	if err != nil {
+		return Symbol{}, err
+	}
+
+	return symbolFromBarcode(bc, false), nil
+}
+
+func QRCode(content string) (Symbol, error) {
+	if content == "" || len(content) > maxSymbolContent {
+		return Symbol{}, errors.New("invalid qr content")
+	}
+
+	bc, err := qr.Encode(content, qr.M, qr.Auto)
+
+	if err != nil {
+		return Symbol{}, err
+	}
+
+	return symbolFromBarcode(bc, true), nil
+}
+
+func (s Symbol) width() int {
+	if len(s.Modules) == 0 {
+		return 0
+	}
+
+	return len(s.Modules[0])
+}
+
+func (s Symbol) quietZone() int {
+	if s.Matrix {
+		return matrixQuietZone
+	}
+
+	return linearQuietZone
+}
+
+// dark reports whether the module at (x, y) is dark. Linear symbols only
+// have one row of modules which is stretched over the whole bar height.
+func (s Symbol) dark(x int, y int) bool {
+	if !s.Matrix {
+		y = 0
+	}
+
+	if y < 0 || y >= len(s.Modules) || x < 0 || x >= s.width() {
+		return false
+	}
+
+	return s.Modules[y][x]
+}
+
+func (s Symbol) rows() int {
+	if s.Matrix {
+		return len(s.Modules)
+	}
+
+	return 1
+}
+
+// runs merges horizontally adjacent dark modules of a row, it keeps the
+// number of shapes small for SVG and PDF output.
+func (s Symbol) runs(y int) [][2]int {
+	var runs [][2]int
+
+	for x := 0; x < s.width(); x++ {
+		if !s.dark(x, y) {
+			continue
+		}
+
+		start := x
+
+		for x < s.width() && s.dark(x, y) {
+			x++
+		}
+
+		runs = append(runs, [2]int{start, x - start})
+	}
+
+	return runs
+}
+
+func (s Symbol) WritePNG(w io.Writer) error {
+	module := pngLinearModule
+	height := linearBarHeight * module
+
+	if s.Matrix {
+		module = pngMatrixModule
+		height = len(s.Modules) * module
+	}
+
+	quiet := s.quietZone() * module
+	verticalQuiet := quiet
+
+	if !s.Matrix {
+		verticalQuiet = module * 4
+	}
+
+	img := image.NewGray(image.Rect(0, 0, s.width()*module+2*quiet, height+2*verticalQuiet))
+
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	for py := 0; py < height; py++ {
+		y := py / module
+
+		for px := 0; px < s.width()*module; px++ {
+			if s.dark(px/module, y) {
+				img.SetGray(quiet+px, verticalQuiet+py, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+func (s Symbol) SVG() []byte {
+	module := svgLinearModule
+	rowHeight := linearBarHeight * module
+
+	if s.Matrix {
+		module = svgMatrixModule
+		rowHeight = module
+	}
+
+	quiet := s.quietZone() * module
+	verticalQuiet := quiet
+
+	if !s.Matrix {
+		verticalQuiet = module * 4
+	}
+
+	width := s.width()*module + 2*quiet
+	height := s.rows()*rowHeight + 2*verticalQuiet
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, width, height)
+
+	for y := 0; y < s.rows(); y++ {
+		for _, run := range s.runs(y) {
+			fmt.Fprintf(&buf, "M%d %dh%dv%dh-%dz", quiet+run[0]*module, verticalQuiet+y*rowHeight, run[1]*module, rowHeight, run[1]*module)
+		}
+	}
+
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	inlineLinkRegex    = regexp.MustCompile(`(\]\(\s*)(<[^>\n]*>|[^\s)]*)`)
+	referenceLinkRegex = regexp.MustCompile(`(?m)^( {0,3}\[[^\]\n]+\]:[ \t]*\n?[ \t]*)(<[^>\n]*>|\S+)`)
+	autolinkRegex      = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9+.\-]*:[^\s<>]*)>`)
+	schemeRegex        = regexp.MustCompile(`^([a-z][a-z0-9+.\-]*):`)
+	controlRegex       = regexp.MustCompile("[\x00-\x08\x0B\x0C\x0E-\x1F\x7F]")
+)
+
+// safeSchemes are the only schemes links may use, links without a scheme
+// (relative paths, anchors) are fine too.
+var safeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// SafeURL tells whether a link destination is allowed. Entities are decoded
+// and whitespace dropped first, the way renderers and browsers read them.
+func SafeURL(raw string) bool {
+	url := html.UnescapeString(strings.TrimSuffix(strings.TrimPrefix(raw, "<"), ">"))
+
+	url = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, url)
+
+	match := schemeRegex.FindStringSubmatch(strings.ToLower(url))
+
+	return match == nil || safeSchemes[match[1]]
+}
+
+func sanitizeLinks(body string, regex *regexp.Regexp) string {
+	return regex.ReplaceAllStringFunc(body, func(link string) string {
+		parts := regex.FindStringSubmatch(link)
+
+		if SafeURL(parts[2]) {
+			return link
+		}
+
+		return parts[1] + "#"
+	})
+}
+
+// SanitizeMarkdown keeps markdown syntax intact but makes it impossible to
+// smuggle raw HTML or script links through it: inline, reference and autolink
+// destinations with a scheme other than http, https or mailto are replaced
+// with "#", and angle brackets that could open a tag are escaped.
+func SanitizeMarkdown(body string) string {
+	body = controlRegex.ReplaceAllString(body, "")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	body = sanitizeLinks(body, inlineLinkRegex)
+	body = sanitizeLinks(body, referenceLinkRegex)
+	body = autolinkRegex.ReplaceAllStringFunc(body, func(link string) string {
+		if SafeURL(link) {
+			return link
+		}
+
+		return "#"
+	})
+
+	body = strings.ReplaceAll(body, "<", "&lt;")
+
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		unsafe string
+	}{
+		{name: "inline link", body: "[x](javascript:alert(1))", unsafe: "javascript:"},
+		{name: "inline link with entities", body: "[x](&#106;avascript&colon;alert(1))", unsafe: "avascript"},
+		{name: "bracketed inline link", body: "[x](<javascript:alert(1)>)", unsafe: "javascript:"},
+		{name: "image", body: "![x](data:text/html;base64,PHNjcmlwdD4=)", unsafe: "data:"},
+		{name: "reference definition", body: "[x]\n\n[x]: javascript:alert(1)", unsafe: "javascript:"},
+		{name: "indented reference definition", body: "[x]\n\n   [x]:\n  <vbscript:msgbox(1)>", unsafe: "vbscript:"},
+		{name: "autolink", body: "see <javascript:alert(1)>", unsafe: "javascript:"},
+		{name: "mixed case scheme", body: "[x](JaVaScRiPt:alert(1))", unsafe: "alert"},
+		{name: "safe inline link", body: "[x](https://example.com/a_(b))", want: "[x](https://example.com/a_(b))"},
+		{name: "safe reference definition", body: "[x]: /books/12 \"title\"", want: "[x]: /books/12 \"title\""},
+		{name: "safe autolink", body: "<mailto:me@example.com>", want: "&lt;mailto:me@example.com>"},
+		{name: "raw html", body: "<script>alert(1)</script>", want: "&lt;script>alert(1)&lt;/script>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := SanitizeMarkdown(test.body)
+
+			if test.want != "" && got != test.want {
+				t.Errorf("SanitizeMarkdown(%q) = %q, want %q", test.body, got, test.want)
+			}
+
+			if test.unsafe != "" && strings.Contains(strings.ToLower(got), test.unsafe) {
+				t.Errorf("SanitizeMarkdown(%q) = %q, still contains %q", test.body, got, test.unsafe)
+			}
+		})
+	}
+}
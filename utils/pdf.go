@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+)
+
+// A4 sheet with 3 x 8 labels, all values are PDF points (1/72 inch).
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	sheetMargin  = 30.0
+	labelColumns = 3
+	labelRows    = 8
+	labelPadding = 6.0
+	qrSize       = 56.0
+)
+
+type Label struct {
+	Title     string
+	Subtitle  string
+	Barcode   string
+	QRContent string
+}
+
+// LabelSheetPDF lays the labels out on as many A4 pages as needed. Barcodes
+// are drawn as vector rectangles so the sheet prints sharp at any scale.
+func LabelSheetPDF(labels []Label) ([]byte, error) {
+	perPage := labelColumns * labelRows
+	labelWidth := (pageWidth - 2*sheetMargin) / labelColumns
+	labelHeight := (pageHeight - 2*sheetMargin) / labelRows
+
+	if len(labels) == 0 {
+		return nil, errors.New("no labels to print")
+	}
+
+	var pages [][]byte
+
+	for start := 0; start < len(labels); start += perPage {
+		end := start + perPage
+
+		if end > len(labels) {
+			end = len(labels)
+		}
+
+		var content bytes.Buffer
+
+		for i, label := range labels[start:end] {
+			column := i % labelColumns
+			row := i / labelColumns
+
+			x := sheetMargin + float64(column)*labelWidth
+			y := pageHeight - sheetMargin - float64(row+1)*labelHeight
+
+			if err := drawLabel(&content, label, x, y, labelWidth, labelHeight); err != nil {
+				return nil, err
+			}
+		}
+
+		pages = append(pages, content.Bytes())
+	}
+
+	return writePDF(pages)
+}
+
+func drawLabel(w *bytes.Buffer, label Label, x float64, y float64, width float64, height float64) error {
+	// light cut guide around the label
+	fmt.Fprintf(w, "0.85 G 0.5 w %.2f %.2f %.2f %.2f re S 0 G\n", x, y, width, height)
+
+	innerX := x + labelPadding
+	innerWidth := width - 2*labelPadding
+	top := y + height - labelPadding
+
+	textWidth := innerWidth
+
+	if label.QRContent != "" {
+		symbol, err := QRCode(label.QRContent)
+
+		if err != nil {
+			return err
+		}
+
+		drawSymbol(w, symbol, x+width-labelPadding-qrSize, y+(height-qrSize)/2, qrSize, qrSize)
+
+		textWidth -= qrSize + labelPadding
+	}
+
+	drawText(w, "F2", 8, innerX, top-8, fitText(label.Title, textWidth, 8))
+	drawText(w, "F1", 7, innerX, top-18, fitText(label.Subtitle, textWidth, 7))
+
+	if label.Barcode != "" {
+		symbol, err := Code128(label.Barcode)
+
+		if err != nil {
+			return err
+		}
+
+		barBottom := y + labelPadding + 10
+		barHeight := top - 24 - barBottom
+
+		drawSymbol(w, symbol, innerX, barBottom, textWidth, barHeight)
+		drawText(w, "F1", 7, innerX, y+labelPadding, fitText(label.Barcode, textWidth, 7))
+	}
+
+	return nil
+}
+
+func drawSymbol(w *bytes.Buffer, symbol Symbol, x float64, y float64, width float64, height float64) {
+	columns := symbol.width()
+
+	if columns == 0 {
+		return
+	}
+
+	if !symbol.Matrix {
+		quiet := float64(symbol.quietZone())
+		module := width / (float64(columns) + 2*quiet)
+		x += quiet * module
+		width -= 2 * quiet * module
+	}
+
+	module := width / float64(columns)
+	rowHeight := height
+
+	if symbol.Matrix {
+		if height < width {
+			module = height / float64(columns)
+		}
+
+		rowHeight = module
+	}
+
+	for row := 0; row < symbol.rows(); row++ {
+		rowY := y + height - float64(row+1)*rowHeight
+
+		for _, run := range symbol.runs(row) {
+			fmt.Fprintf(w, "%.3f %.3f %.3f %.3f re\n", x+float64(run[0])*module, rowY, float64(run[1])*module, rowHeight)
+		}
+	}
+
+	w.WriteString("f\n")
+}
+
+func drawText(w *bytes.Buffer, font string, size float64, x float64, y float64, text string) {
+	if text == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// fitText trims text to what roughly fits the width, Helvetica glyphs average
+// a bit over half of the font size.
+func fitText(text string, width float64, size float64) string {
+	runes := []rune(text)
+	max := int(width / (size * 0.55))
+
+	if len(runes) <= max || max < 4 {
+		return text
+	}
+
+	return string(runes[:max-3]) + "..."
+}
+
+// pdfString escapes text for a literal string in WinAnsi encoding, runes
+// outside Latin-1 can't be shown with the standard fonts and become "?".
+func pdfString(text string) string {
+	var buf bytes.Buffer
+
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r >= 32 && r < 127:
+			buf.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&buf, "\\%03o", r)
+		default:
+			buf.WriteByte('?')
+		}
+	}
+
+	return buf.String()
+}
+
+func writePDF(pages [][]byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	offsets := []int{}
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	kids := ""
+
+	for i := range pages {
+		kids += fmt.Sprintf("%d 0 R ", 5+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, content := range pages {
+		var compressed bytes.Buffer
+
+		zw := zlib.NewWriter(&compressed)
+
+		if _, err := zw.Write(content); err != nil {
+			return nil, err
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", len(offsets), compressed.Len())
+		out.Write(compressed.Bytes())
+		out.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := out.Len()
+
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes(), nil
+}
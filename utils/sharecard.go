@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	cardSize    = 1080
+	cardPadding = 80
+)
+
+var (
+	cardTop    = color.RGBA{R: 30, G: 41, B: 82, A: 255}
+	cardBottom = color.RGBA{R: 88, G: 40, B: 110, A: 255}
+	cardText   = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	cardMuted  = color.RGBA{R: 196, G: 190, B: 230, A: 255}
+	cardAccent = color.RGBA{R: 255, G: 196, B: 87, A: 255}
+)
+
+type CardStat struct {
+	Label string
+	Value string
+}
+
+// ShareCard is a square image meant for social media: a title, up to four
+// headline numbers, a small bar chart and a couple of lines of highlights.
+type ShareCard struct {
+	Title       string
+	Subtitle    string
+	Stats       []CardStat
+	Chart       []int
+	ChartLabels []string
+	Highlights  []string
+	Footer      string
+}
+
+// fitScale picks the biggest scale, up to largest, at which text fits.
+func fitScale(text string, largest int, width int) int {
+	scale := largest
+
+	for scale > 1 && TextWidth(text, scale) > width {
+		scale--
+	}
+
+	return scale
+}
+
+// fitCardText shortens text with an ellipsis until it fits at the given scale.
+func fitCardText(text string, scale int, width int) string {
+	runes := []rune(text)
+
+	if TextWidth(text, scale) <= width {
+		return text
+	}
+
+	for len(runes) > 0 && TextWidth(string(runes)+"...", scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "..."
+}
+
+func drawCentered(img *image.RGBA, y int, text string, scale int, c color.Color) {
+	DrawText(img, (cardSize-TextWidth(text, scale))/2, y, text, scale, c)
+}
+
+func (card ShareCard) Render() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, cardSize, cardSize))
+	width := cardSize - 2*cardPadding
+
+	for y := 0; y < cardSize; y++ {
+		t := float64(y) / float64(cardSize-1)
+		row := color.RGBA{
+			R: uint8(float64(cardTop.R)*(1-t) + float64(cardBottom.R)*t),
+			G: uint8(float64(cardTop.G)*(1-t) + float64(cardBottom.G)*t),
+			B: uint8(float64(cardTop.B)*(1-t) + float64(cardBottom.B)*t),
+			A: 255,
+		}
+		fillRect(img, image.Rect(0, y, cardSize, y+1), row)
+	}
+
+	y := cardPadding
+
+	titleScale := fitScale(card.Title, 12, width)
+	drawCentered(img, y, card.Title, titleScale, cardAccent)
+	y += TextHeight(titleScale) + 30
+
+	if card.Subtitle != "" {
+		subtitle := fitCardText(card.Subtitle, 5, width)
+		drawCentered(img, y, subtitle, 5, cardMuted)
+		y += TextHeight(5) + 60
+	}
+
+	stats := card.Stats
+
+	if len(stats) > 4 {
+		stats = stats[:4]
+	}
+
+	columnWidth := width / 2
+
+	for i, stat := range stats {
+		x := cardPadding + (i%2)*columnWidth
+		top := y + (i/2)*150
+
+		valueScale := fitScale(stat.Value, 11, columnWidth-20)
+		DrawText(img, x, top, stat.Value, valueScale, cardText)
+		DrawText(img, x, top+TextHeight(valueScale)+14, fitCardText(stat.Label, 3, columnWidth-20), 3, cardMuted)
+	}
+
+	y += ((len(stats) + 1) / 2) * 150
+
+	if len(card.Chart) > 0 {
+		y += 10
+		chartHeight := 160
+		barSlot := width / len(card.Chart)
+		highest := 0
+
+		for _, value := range card.Chart {
+			if value > highest {
+				highest = value
+			}
+		}
+
+		for i, value := range card.Chart {
+			x := cardPadding + i*barSlot
+			barHeight := 4
+
+			if highest > 0 {
+				barHeight = max(4, value*chartHeight/highest)
+			}
+
+			fillRect(img, image.Rect(x+barSlot/6, y+chartHeight-barHeight, x+barSlot-barSlot/6, y+chartHeight), cardAccent)
+
+			if i < len(card.ChartLabels) {
+				label := card.ChartLabels[i]
+				DrawText(img, x+(barSlot-TextWidth(label, 3))/2, y+chartHeight+12, label, 3, cardMuted)
+			}
+		}
+
+		y += chartHeight + 12 + TextHeight(3) + 40
+	}
+
+	footerY := cardSize - cardPadding/2 - TextHeight(4)
+
+	for _, highlight := range card.Highlights {
+		if y+TextHeight(4) > footerY-20 {
+			break
+		}
+
+		DrawText(img, cardPadding, y, fitCardText(highlight, 4, width), 4, cardText)
+		y += TextHeight(4) + 22
+	}
+
+	if card.Footer != "" {
+		drawCentered(img, footerY, card.Footer, 4, cardMuted)
+	}
+
+	return img
+}
+
+func (card ShareCard) WritePNG(w io.Writer) error {
+	return png.Encode(w, card.Render())
+}
@@ -0,0 +1,17 @@
+package utils
+
+import "regexp"
+
+const HiddenSpoiler = "[spoiler hidden]"
+
+var spoilerRegex = regexp.MustCompile(`(?is)\[spoiler\](.*?)\[/spoiler\]`)
+
+func HasSpoilers(text string) bool {
+	return spoilerRegex.MatchString(text)
+}
+
+// HideSpoilers replaces every [spoiler]...[/spoiler] span with a placeholder,
+// text outside of the spans is returned untouched.
+func HideSpoilers(text string) string {
+	return spoilerRegex.ReplaceAllString(text, HiddenSpoiler)
+}